@@ -0,0 +1,209 @@
+// Package analyzer exposes the quotedconv conversion as a go/analysis analyzer, so
+// the string literals it would convert can be reported by vet-style drivers and
+// linter aggregators.
+package analyzer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/token"
+	"slices"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+
+	"github.com/otakakot/quotedconv/quotedconv"
+)
+
+const doc = `report string literals quotedconv would convert
+
+The quotedconv analyzer reports every string literal the quotedconv command would
+rewrite, honouring the same options and //quotedconv directives.`
+
+// Analyzer reports the literals converted with the default options.
+var Analyzer = newDefault()
+
+// New returns an analyzer reporting the literals c would convert.
+func New(c *quotedconv.Converter) *analysis.Analyzer {
+	return &analysis.Analyzer{
+		Name: "quotedconv",
+		Doc:  doc,
+		URL:  "https://github.com/otakakot/quotedconv",
+		Run: func(pass *analysis.Pass) (any, error) {
+			return nil, run(pass, c)
+		},
+	}
+}
+
+func newDefault() *analysis.Analyzer {
+	c, err := quotedconv.New(quotedconv.WithLogger(nil))
+	if err != nil {
+		panic(fmt.Sprintf("default converter: %v", err))
+	}
+
+	return New(c)
+}
+
+// fixMessages are the titles of the suggested fixes by rule, which gopls offers as
+// code actions.
+var fixMessages = map[string]string{
+	quotedconv.RuleRawToInterpreted: "Convert to interpreted string",
+	quotedconv.RuleInterpretedToRaw: "Convert to raw string",
+	quotedconv.RuleNormalizeEscapes: "Normalize escapes",
+}
+
+// run reports a diagnostic for every literal c would convert in the files of pass,
+// read through pass.ReadFile so that drivers such as gopls can supply unsaved
+// buffers, with a suggested fix applying the conversion.
+func run(pass *analysis.Pass, c *quotedconv.Converter) error {
+	for _, file := range pass.Files {
+		tf := pass.Fset.File(file.FileStart)
+		if tf == nil || !strings.HasSuffix(tf.Name(), ".go") {
+			continue
+		}
+
+		src, err := pass.ReadFile(tf.Name())
+		if err != nil {
+			return fmt.Errorf("read file: %w", err)
+		}
+
+		result, err := c.Convert(context.Background(), tf.Name(), src)
+		if errors.Is(err, quotedconv.ErrFileIgnored) {
+			continue
+		}
+
+		if err != nil {
+			return fmt.Errorf("%s: %w", tf.Name(), err)
+		}
+
+		for _, group := range groupByDecl(file, tf, result.Changes) {
+			report(pass, tf, src, group)
+		}
+	}
+
+	return nil
+}
+
+// declChanges are the changes within one top-level declaration, or outside any
+// declaration when decl is nil.
+type declChanges struct {
+	decl    ast.Decl
+	changes []quotedconv.Change
+}
+
+// groupByDecl groups changes, which are in source order, by their top-level
+// declaration in file.
+func groupByDecl(file *ast.File, tf *token.File, changes []quotedconv.Change) []declChanges {
+	var groups []declChanges
+
+	for _, change := range changes {
+		var decl ast.Decl
+
+		for _, d := range file.Decls {
+			if change.Pos.Offset >= tf.Offset(d.Pos()) && change.End.Offset <= tf.Offset(d.End()) {
+				decl = d
+
+				break
+			}
+		}
+
+		if n := len(groups); n > 0 && decl != nil && groups[n-1].decl == decl {
+			groups[n-1].changes = append(groups[n-1].changes, change)
+
+			continue
+		}
+
+		groups = append(groups, declChanges{decl: decl, changes: []quotedconv.Change{change}})
+	}
+
+	return groups
+}
+
+// report reports the changes of group. Each change gets a diagnostic whose fix
+// replaces just the literal, unless the declaration is left unformatted once all its
+// changes are applied, such as when a longer literal shifts aligned comments. Then a
+// single diagnostic covers every change of the declaration, with a fix replacing the
+// declaration by its formatted form, so that fixes never overlap and applying one
+// alone leaves the file gofmt-clean.
+func report(pass *analysis.Pass, tf *token.File, src []byte, group declChanges) {
+	if group.decl != nil {
+		if edit, ok := declEdit(tf, src, group.decl, group.changes); ok {
+			messages := make([]string, len(group.changes))
+			for i, change := range group.changes {
+				messages[i] = message(change)
+			}
+
+			first, last := group.changes[0], group.changes[len(group.changes)-1]
+
+			fixMessage := fixMessages[first.Rule]
+			if len(group.changes) > 1 {
+				fixMessage = fmt.Sprintf("Convert %d string literals", len(group.changes))
+			}
+
+			pass.Report(analysis.Diagnostic{
+				Pos:      tf.Pos(first.Pos.Offset),
+				End:      tf.Pos(last.End.Offset),
+				Category: first.Rule,
+				Message:  strings.Join(messages, "; "),
+				URL:      "",
+				SuggestedFixes: []analysis.SuggestedFix{{
+					Message:   fixMessage,
+					TextEdits: []analysis.TextEdit{edit},
+				}},
+				Related: nil,
+			})
+
+			return
+		}
+	}
+
+	for _, change := range group.changes {
+		pass.Report(analysis.Diagnostic{
+			Pos:      tf.Pos(change.Pos.Offset),
+			End:      tf.Pos(change.End.Offset),
+			Category: change.Rule,
+			Message:  message(change),
+			URL:      "",
+			SuggestedFixes: []analysis.SuggestedFix{{
+				Message: fixMessages[change.Rule],
+				TextEdits: []analysis.TextEdit{{
+					Pos:     tf.Pos(change.Pos.Offset),
+					End:     tf.Pos(change.End.Offset),
+					NewText: []byte(change.After),
+				}},
+			}},
+			Related: nil,
+		})
+	}
+}
+
+func message(change quotedconv.Change) string {
+	return strings.Join(strings.Fields(change.Rule+": "+change.Detail), " ")
+}
+
+// declEdit returns the edit replacing decl, in src, by its formatted form with changes
+// applied, and false if applying the changes alone leaves it formatted.
+func declEdit(tf *token.File, src []byte, decl ast.Decl, changes []quotedconv.Change) (analysis.TextEdit, bool) {
+	start, end := tf.Offset(decl.Pos()), tf.Offset(decl.End())
+
+	var text []byte
+
+	offset := start
+	for _, change := range changes {
+		text = slices.Concat(text, src[offset:change.Pos.Offset], []byte(change.After))
+		offset = change.End.Offset
+	}
+
+	text = slices.Concat(text, src[offset:end])
+
+	formatted, err := format.Source(text)
+	if err != nil || bytes.Equal(formatted, text) {
+		return analysis.TextEdit{}, false
+	}
+
+	return analysis.TextEdit{Pos: decl.Pos(), End: decl.End(), NewText: formatted}, true
+}
@@ -0,0 +1,18 @@
+package a
+
+var greeting = `hello` // want `raw-to-interpreted: 0 escapes added`
+
+const (
+	A   = `a`        // want `raw-to-interpreted: 0 escapes added; raw-to-interpreted: 1 escapes added; raw-to-interpreted: 1 escapes added`
+	BB  = `tab	here` // aligned
+	CCC = `x	y`      // aligned
+)
+
+var kept = "kept"
+
+func f() string {
+	x := `x` // want `raw-to-interpreted: 0 escapes added`
+	y := `y` // want `raw-to-interpreted: 0 escapes added`
+
+	return x + y
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"runtime/debug"
+	"time"
+)
+
+// auditRecord is one line of the audit log. Records are only ever appended.
+type auditRecord struct {
+	SchemaVersion int         `json:"schemaVersion"`
+	Time          time.Time   `json:"time"`
+	RunID         string      `json:"runId"`
+	Hostname      string      `json:"hostname"`
+	ToolVersion   string      `json:"toolVersion"`
+	ConfigHash    string      `json:"configHash"`
+	Files         []auditFile `json:"files"`
+}
+
+type auditFile struct {
+	Path         string `json:"path"`
+	BeforeSHA256 string `json:"beforeSha256"`
+	AfterSHA256  string `json:"afterSha256"`
+}
+
+func appendAuditRecord(path string, rep *report) error {
+	record := auditRecord{
+		SchemaVersion: rep.SchemaVersion,
+		Time:          time.Now().UTC(),
+		RunID:         rep.Run.ID,
+		Hostname:      rep.Run.Hostname,
+		ToolVersion:   rep.Run.ToolVersion,
+		ConfigHash:    rep.Run.ConfigHash,
+		Files:         make([]auditFile, 0, len(rep.Files)),
+	}
+
+	for _, f := range rep.Files {
+		// Files that were not rewritten have no after hash.
+		if f.AfterSHA256 == "" {
+			continue
+		}
+
+		record.Files = append(record.Files, auditFile{Path: f.Path, BeforeSHA256: f.BeforeSHA256, AfterSHA256: f.AfterSHA256})
+	}
+
+	line, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("encode record: %w", err)
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return fmt.Errorf("open: %w", err)
+	}
+
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+
+		return fmt.Errorf("write: %w", err)
+	}
+
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("close: %w", err)
+	}
+
+	return nil
+}
+
+func toolVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+
+	return info.Main.Version
+}
+
+// configHash identifies the effective options of a run.
+func configHash(opts Options) string {
+	data, err := json.Marshal(opts)
+	if err != nil {
+		return ""
+	}
+
+	return sha256Hex(data)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+
+	return hex.EncodeToString(sum[:])
+}
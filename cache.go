@@ -0,0 +1,68 @@
+package main
+
+import (
+	"slices"
+	"sync"
+
+	"github.com/otakakot/quotedconv/quotedconv"
+)
+
+// decisionCacheSize bounds the number of cached decisions.
+const decisionCacheSize = 4096
+
+// decisionCache remembers conversion results by content hash, so long-running modes
+// answer repeated saves of the same content without parsing again. A nil cache is
+// valid and never hits.
+type decisionCache struct {
+	mu      sync.Mutex
+	size    int
+	entries map[string]quotedconv.Result
+}
+
+func newDecisionCache(size int) *decisionCache {
+	return &decisionCache{
+		mu:      sync.Mutex{},
+		size:    size,
+		entries: make(map[string]quotedconv.Result, size),
+	}
+}
+
+// get returns the cached decision for key with change positions attributed to filename.
+func (c *decisionCache) get(key, filename string) (quotedconv.Result, bool) {
+	if c == nil {
+		return quotedconv.Result{Changes: nil, Source: nil, Suppressed: nil}, false
+	}
+
+	c.mu.Lock()
+	d, ok := c.entries[key]
+	c.mu.Unlock()
+
+	if !ok {
+		return d, false
+	}
+
+	d.Changes = slices.Clone(d.Changes)
+	for i := range d.Changes {
+		d.Changes[i].Pos.Filename = filename
+		d.Changes[i].End.Filename = filename
+	}
+
+	return d, true
+}
+
+func (c *decisionCache) put(key string, result quotedconv.Result) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.entries) >= c.size {
+		// Dropping everything is crude but keeps memory bounded without bookkeeping;
+		// the working set of a watched tree refills quickly.
+		clear(c.entries)
+	}
+
+	c.entries[key] = result
+}
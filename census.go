@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"path/filepath"
+
+	"github.com/otakakot/quotedconv/quotedconv"
+	"golang.org/x/tools/go/packages"
+)
+
+// literalCensus loads the packages containing path with type information and counts
+// the literals that would be converted by the type they are used as: "string", a named
+// string type such as "template.HTML", or, for the operand of a conversion to a
+// non-string type, the conversion such as "json.RawMessage(...)". It must run before
+// the literals are converted.
+func (p *Processor) literalCensus(ctx context.Context, path string) (map[string]int, error) {
+	// Package patterns are resolved relative to the working directory.
+	dir, pattern := "", path
+
+	if !isPackagePattern(p.fsys, path) {
+		info, err := p.fsys.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("stat path: %w", err)
+		}
+
+		dir, pattern = path, "./..."
+
+		if !info.IsDir() {
+			abs, err := filepath.Abs(path)
+			if err != nil {
+				return nil, fmt.Errorf("resolve path: %w", err)
+			}
+
+			dir, pattern = filepath.Dir(abs), "file="+abs
+		}
+	}
+
+	cfg := &packages.Config{
+		Mode:    packages.NeedName | packages.NeedFiles | packages.NeedImports | packages.NeedDeps | packages.NeedSyntax | packages.NeedTypes | packages.NeedTypesInfo,
+		Context: ctx,
+		Dir:     dir,
+		Tests:   true,
+		Overlay: p.overlay,
+	}
+
+	pkgs, err := packages.Load(cfg, pattern)
+	if err != nil {
+		return nil, fmt.Errorf("load packages: %w", err)
+	}
+
+	census := map[string]int{}
+	// Test variants of a package share files; every literal is counted once.
+	seen := map[token.Position]bool{}
+
+	for _, pkg := range pkgs {
+		if pkg.TypesInfo == nil {
+			continue
+		}
+
+		qualifier := func(other *types.Package) string {
+			if other == pkg.Types {
+				return ""
+			}
+
+			return other.Name()
+		}
+
+		for _, file := range pkg.Syntax {
+			ast.Inspect(file, func(n ast.Node) bool {
+				switch n := n.(type) {
+				case *ast.ImportSpec:
+					// Import paths are not values.
+					return false
+				case *ast.Field:
+					// Struct tags are never converted.
+					if n.Tag != nil {
+						seen[pkg.Fset.Position(n.Tag.Pos())] = true
+					}
+				}
+
+				if call, ok := n.(*ast.CallExpr); ok && len(call.Args) == 1 {
+					if lit, ok := call.Args[0].(*ast.BasicLit); ok && pkg.TypesInfo.Types[call.Fun].IsType() {
+						target := pkg.TypesInfo.Types[call.Fun].Type
+						if !isStringType(target) {
+							p.countLiteral(census, seen, pkg.Fset.Position(lit.Pos()), lit, types.TypeString(target, qualifier)+"(...)")
+
+							return false
+						}
+					}
+				}
+
+				lit, ok := n.(*ast.BasicLit)
+				if !ok || lit.Kind != token.STRING {
+					return true
+				}
+
+				name := "string"
+				if tv, ok := pkg.TypesInfo.Types[lit]; ok && tv.Type != nil {
+					name = types.TypeString(types.Default(tv.Type), qualifier)
+				}
+
+				p.countLiteral(census, seen, pkg.Fset.Position(lit.Pos()), lit, name)
+
+				return true
+			})
+		}
+	}
+
+	return census, nil
+}
+
+func (p *Processor) countLiteral(census map[string]int, seen map[token.Position]bool, pos token.Position, lit *ast.BasicLit, name string) {
+	if lit.Kind != token.STRING || seen[pos] {
+		return
+	}
+
+	seen[pos] = true
+
+	if _, ok := quotedconv.ConvertLiteral(lit.Value, p.opts.Options); ok {
+		census[name]++
+	}
+}
+
+func isStringType(t types.Type) bool {
+	basic, ok := t.Underlying().(*types.Basic)
+
+	return ok && basic.Info()&types.IsString != 0
+}
@@ -0,0 +1,17 @@
+// Command quotedconvvet runs the quotedconv analyzer as a vet tool, reporting the
+// string literals quotedconv would convert:
+//
+//	go vet -vettool=$(which quotedconvvet) ./...
+//
+// The go command loads the packages, honouring build tags, and caches the results.
+package main
+
+import (
+	"golang.org/x/tools/go/analysis/unitchecker"
+
+	"github.com/otakakot/quotedconv/analyzer"
+)
+
+func main() {
+	unitchecker.Main(analyzer.Analyzer)
+}
@@ -0,0 +1,73 @@
+//go:build js && wasm
+
+// Command quotedconvwasm exposes the conversion to JavaScript, for browser playgrounds
+// and web-based review tools. Built with GOOS=js GOARCH=wasm and started with the
+// wasm_exec.js support file of the Go distribution, it defines a global quotedconv
+// object whose Convert(source) converts a Go source file with the default options:
+//
+//	const converted = quotedconv.Convert(source);
+//
+// Source that cannot be converted, such as source that does not parse, is returned
+// unchanged and the error is logged to the console.
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"syscall/js"
+
+	"github.com/otakakot/quotedconv/quotedconv"
+)
+
+// console is a quotedconv.Logger writing warnings to the JavaScript console. Writing
+// to os.Stderr instead would deadlock: it waits for the event loop, which is blocked
+// while a JavaScript call into Go runs.
+type console struct{}
+
+func (console) Printf(format string, args ...any) {
+	js.Global().Get("console").Call("warn", fmt.Sprintf(format, args...))
+}
+
+var converter *quotedconv.Converter
+
+func main() {
+	var err error
+	if converter, err = quotedconv.New(quotedconv.WithLogger(console{})); err != nil {
+		panic("Error: " + err.Error())
+	}
+
+	js.Global().Set("quotedconv", js.ValueOf(map[string]any{
+		"Convert": js.FuncOf(convert),
+	}))
+
+	// The functions must outlive main.
+	select {}
+}
+
+func convert(_ js.Value, args []js.Value) any {
+	if len(args) != 1 || args[0].Type() != js.TypeString {
+		console{}.Printf("quotedconv.Convert: want a single string argument")
+
+		return js.Undefined()
+	}
+
+	return Convert(args[0].String())
+}
+
+// Convert returns source with its literals converted with the default options, or
+// source unchanged if it cannot be converted.
+func Convert(source string) string {
+	result, err := converter.Convert(context.Background(), "", []byte(source))
+	if err != nil && !errors.Is(err, quotedconv.ErrFileIgnored) {
+		console{}.Printf("quotedconv.Convert: %v", err)
+
+		return source
+	}
+
+	if len(result.Changes) == 0 {
+		return source
+	}
+
+	return string(result.Source)
+}
@@ -0,0 +1,414 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// configName is the name of the project configuration file.
+const configName = ".quotedconv.toml"
+
+// config is a parsed configuration file. Its keys are flag names; values are set as if
+// given on the command line.
+type config struct {
+	path    string
+	entries []configEntry
+	// dirs are the [dir."PATTERN"] tables, which override conversion options for the
+	// files below the directories matching their pattern.
+	dirs []dirTable
+}
+
+// dirTable is a [dir."PATTERN"] table of a configuration file.
+type dirTable struct {
+	// pattern is a glob matched against directories relative to the directory of the
+	// configuration file.
+	pattern string
+	entries []configEntry
+}
+
+// configEntry is a key = value line of a configuration file.
+type configEntry struct {
+	line int
+	key  string
+	// values holds the value, or the elements of an array value.
+	values []string
+	array  bool
+}
+
+// findConfig returns the nearest configuration file in fsys in the directory of start,
+// or in start itself if it is a directory, or in any directory above it. It returns ""
+// if there is none.
+func findConfig(fsys FS, start string) (string, error) {
+	dir, err := filepath.Abs(start)
+	if err != nil {
+		return "", fmt.Errorf("resolve path: %w", err)
+	}
+
+	if info, err := fsys.Stat(dir); err != nil || !info.IsDir() {
+		dir = filepath.Dir(dir)
+	}
+
+	for {
+		candidate := filepath.Join(dir, configName)
+		if _, err := fsys.Stat(candidate); err == nil {
+			return candidate, nil
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+
+		dir = parent
+	}
+}
+
+// loadConfig reads and parses the configuration file path in fsys.
+func loadConfig(fsys FS, path string) (*config, error) {
+	data, err := fsys.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read config: %w", err)
+	}
+
+	return parseConfig(path, string(data))
+}
+
+// parseConfig parses the subset of TOML configuration files are written in: comments,
+// [dir."PATTERN"] table headers, and key = value pairs whose value is a string, an
+// integer, a boolean, or an array of those, which may span several lines.
+func parseConfig(path, data string) (*config, error) {
+	cfg := &config{path: path, entries: nil, dirs: nil}
+	seen := map[string]bool{}
+	lines := strings.Split(data, "\n")
+	entries := &cfg.entries
+	patterns := map[string]bool{}
+
+	for i := 0; i < len(lines); i++ {
+		lineNo := i + 1
+		line := strings.TrimSpace(stripComment(lines[i]))
+
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			pattern, err := parseDirHeader(line)
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: %w", path, lineNo, err)
+			}
+
+			if patterns[pattern] {
+				return nil, fmt.Errorf("%s:%d: duplicate table for %q", path, lineNo, pattern)
+			}
+
+			patterns[pattern] = true
+			seen = map[string]bool{}
+			cfg.dirs = append(cfg.dirs, dirTable{pattern: pattern, entries: nil})
+			entries = &cfg.dirs[len(cfg.dirs)-1].entries
+
+			continue
+		}
+
+		rawKey, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: want key = value", path, lineNo)
+		}
+
+		key, err := parseKey(strings.TrimSpace(rawKey))
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, lineNo, err)
+		}
+
+		if seen[key] {
+			return nil, fmt.Errorf("%s:%d: duplicate key %q", path, lineNo, key)
+		}
+
+		seen[key] = true
+
+		value = strings.TrimSpace(value)
+
+		// An array continues until its brackets balance.
+		for strings.HasPrefix(value, "[") && !arrayClosed(value) && i+1 < len(lines) {
+			i++
+			value += " " + strings.TrimSpace(stripComment(lines[i]))
+		}
+
+		if strings.HasPrefix(value, "[") && !arrayClosed(value) {
+			return nil, fmt.Errorf("%s:%d: %s: unterminated array", path, lineNo, key)
+		}
+
+		values, array, err := parseValue(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %s: %w", path, lineNo, key, err)
+		}
+
+		*entries = append(*entries, configEntry{line: lineNo, key: key, values: values, array: array})
+	}
+
+	return cfg, nil
+}
+
+// apply sets the flags of fs named by entries, which belong to cfg, skipping those in
+// set, which were given on the command line or in the environment. A setting replaces the value of its flag:
+// an array sets a repeatable flag once per element, after clearing it, and any other
+// flag to its comma-separated elements.
+func (cfg *config) apply(fs *flag.FlagSet, entries []configEntry, set map[string]bool) error {
+	for _, e := range entries {
+		f := fs.Lookup(e.key)
+		if f == nil || e.key == "config" {
+			return fmt.Errorf("%s:%d: unknown setting %q", cfg.path, e.line, e.key)
+		}
+
+		if set[e.key] {
+			continue
+		}
+
+		values := e.values
+
+		switch v := f.Value.(type) {
+		case *stringList:
+			*v = nil
+		case *commaList:
+			*v = nil
+		case *rewriteRules:
+			*v = nil
+		default:
+			if e.array {
+				values = []string{strings.Join(values, ",")}
+			}
+		}
+
+		for _, value := range values {
+			if err := fs.Set(e.key, value); err != nil {
+				return fmt.Errorf("%s:%d: %s: %w", cfg.path, e.line, e.key, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// parseDirHeader parses a [dir."PATTERN"] table header and returns its pattern.
+func parseDirHeader(line string) (string, error) {
+	inner, ok := strings.CutSuffix(strings.TrimPrefix(line, "["), "]")
+	name, key, dotted := strings.Cut(inner, ".")
+
+	if !ok || strings.HasPrefix(inner, "[") || strings.TrimSpace(name) != "dir" || !dotted {
+		return "", errors.New(`only [dir."PATTERN"] tables are supported`)
+	}
+
+	pattern, err := parseKey(strings.TrimSpace(key))
+	if err != nil {
+		return "", err
+	}
+
+	pattern = strings.TrimSuffix(pattern, "/")
+	if pattern == "" {
+		return "", errors.New("empty directory pattern")
+	}
+
+	if err := validGlob(pattern); err != nil {
+		return "", fmt.Errorf("invalid directory pattern %q: %w", pattern, err)
+	}
+
+	return pattern, nil
+}
+
+// stripComment removes a # comment that is not inside a string from line.
+func stripComment(line string) string {
+	var quote byte
+
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote == 0 && (c == '"' || c == '\''):
+			quote = c
+		case quote == 0 && c == '#':
+			return line[:i]
+		}
+	}
+
+	return line
+}
+
+// arrayClosed reports whether the brackets of the array value balance.
+func arrayClosed(value string) bool {
+	depth := 0
+
+	var quote byte
+
+	for i := 0; i < len(value); i++ {
+		switch c := value[i]; {
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote == 0 && (c == '"' || c == '\''):
+			quote = c
+		case quote == 0 && c == '[':
+			depth++
+		case quote == 0 && c == ']':
+			depth--
+		}
+	}
+
+	return depth <= 0
+}
+
+func parseKey(key string) (string, error) {
+	if strings.HasPrefix(key, `"`) {
+		return strconv.Unquote(key)
+	}
+
+	if key == "" || strings.TrimLeft(key, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_-") != "" {
+		return "", fmt.Errorf("invalid key %q", key)
+	}
+
+	return key, nil
+}
+
+// parseValue parses a scalar value, or an array of scalars.
+func parseValue(value string) (values []string, array bool, err error) {
+	if !strings.HasPrefix(value, "[") {
+		scalar, rest, err := parseScalar(value)
+		if err != nil {
+			return nil, false, err
+		}
+
+		if strings.TrimSpace(rest) != "" {
+			return nil, false, fmt.Errorf("unexpected %q after value", rest)
+		}
+
+		return []string{scalar}, false, nil
+	}
+
+	rest := strings.TrimSpace(value[1:])
+
+	for !strings.HasPrefix(rest, "]") {
+		scalar, after, err := parseScalar(rest)
+		if err != nil {
+			return nil, true, err
+		}
+
+		values = append(values, scalar)
+		rest = strings.TrimSpace(after)
+
+		if next, ok := strings.CutPrefix(rest, ","); ok {
+			rest = strings.TrimSpace(next)
+		} else if !strings.HasPrefix(rest, "]") {
+			return nil, true, errors.New("want , or ] in array")
+		}
+	}
+
+	if strings.TrimSpace(rest[1:]) != "" {
+		return nil, true, fmt.Errorf("unexpected %q after array", rest[1:])
+	}
+
+	return values, true, nil
+}
+
+// parseScalar parses the string, integer or boolean at the start of s and returns it
+// with the rest of s.
+func parseScalar(s string) (value, rest string, err error) {
+	switch {
+	case strings.HasPrefix(s, `"`):
+		for i := 1; i < len(s); i++ {
+			switch s[i] {
+			case '\\':
+				i++
+			case '"':
+				value, err := strconv.Unquote(s[:i+1])
+				if err != nil {
+					return "", "", fmt.Errorf("invalid string %s", s[:i+1])
+				}
+
+				return value, s[i+1:], nil
+			}
+		}
+
+		return "", "", errors.New("unterminated string")
+	case strings.HasPrefix(s, "'"):
+		end := strings.IndexByte(s[1:], '\'')
+		if end < 0 {
+			return "", "", errors.New("unterminated string")
+		}
+
+		return s[1 : end+1], s[end+2:], nil
+	}
+
+	end := strings.IndexAny(s, ", \t]")
+	if end < 0 {
+		end = len(s)
+	}
+
+	token := s[:end]
+
+	if token == "true" || token == "false" {
+		return token, s[end:], nil
+	}
+
+	if _, err := strconv.ParseInt(strings.ReplaceAll(token, "_", ""), 10, 64); err == nil {
+		return strings.ReplaceAll(token, "_", ""), s[end:], nil
+	}
+
+	return "", "", fmt.Errorf("invalid value %q; strings must be quoted", token)
+}
+
+// shorthands maps the shorthand flags to the flag of the option they set.
+var shorthands = map[string]string{
+	"escape-quotes": "quotes",
+	"ascii":         "escaping",
+	"keep-unicode":  "escaping",
+}
+
+// pinnedFlags returns the names of the flags set in fs, with the flags of the options
+// set by the shorthands among them, which later sources must not override either.
+func pinnedFlags(fs *flag.FlagSet) map[string]bool {
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+		if name, ok := shorthands[f.Name]; ok {
+			set[name] = true
+		}
+	})
+
+	return set
+}
+
+// applyConfigFile applies the configuration file path to the flags of fs that were not
+// already set, on the command line or from the environment. Without a path, the file is
+// looked up from the first argument, or from the working directory if it is not a file
+// or directory. It returns the path of the applied file, or "" if there is none.
+func applyConfigFile(fsys FS, fs *flag.FlagSet, path string) (string, error) {
+	set := pinnedFlags(fs)
+
+	if path == "" {
+		start := "."
+		if arg := fs.Arg(0); arg != "" {
+			if _, err := fsys.Stat(arg); err == nil {
+				start = arg
+			}
+		}
+
+		found, err := findConfig(fsys, start)
+		if err != nil || found == "" {
+			return "", err
+		}
+
+		path = found
+	}
+
+	cfg, err := loadConfig(fsys, path)
+	if err != nil {
+		return "", err
+	}
+
+	return path, cfg.apply(fs, cfg.entries, set)
+}
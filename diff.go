@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// diffStatWidth is the maximum width of the +/- graph of a diffstat line.
+const diffStatWidth = 40
+
+// diffStatReport renders a git diff --stat style summary of the rewritten files.
+func diffStatReport(rep *report) string {
+	if len(rep.Files) == 0 {
+		return ""
+	}
+
+	nameWidth, maxChanges, insertions, deletions := 0, 0, 0, 0
+
+	for _, f := range rep.Files {
+		nameWidth = max(nameWidth, len(f.Path))
+		maxChanges = max(maxChanges, f.Insertions+f.Deletions)
+		insertions += f.Insertions
+		deletions += f.Deletions
+	}
+
+	countWidth := len(strconv.Itoa(maxChanges))
+
+	var b strings.Builder
+
+	for _, f := range rep.Files {
+		plus, minus := f.Insertions, f.Deletions
+		if maxChanges > diffStatWidth {
+			plus = scaleStat(plus, maxChanges)
+			minus = scaleStat(minus, maxChanges)
+		}
+
+		fmt.Fprintf(&b, " %-*s | %*d %s%s\n", nameWidth, f.Path, countWidth, f.Insertions+f.Deletions,
+			strings.Repeat("+", plus), strings.Repeat("-", minus))
+	}
+
+	fmt.Fprintf(&b, " %d %s changed, %d %s(+), %d %s(-)\n",
+		len(rep.Files), plural(len(rep.Files), "file", "files"),
+		insertions, plural(insertions, "insertion", "insertions"),
+		deletions, plural(deletions, "deletion", "deletions"))
+
+	return b.String()
+}
+
+func scaleStat(n, total int) int {
+	if n == 0 {
+		return 0
+	}
+
+	return max(n*diffStatWidth/total, 1)
+}
+
+func plural(n int, singular, pluralForm string) string {
+	if n == 1 {
+		return singular
+	}
+
+	return pluralForm
+}
@@ -0,0 +1,272 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/otakakot/quotedconv/quotedconv"
+)
+
+// dirConfigs resolves the conversion options of every file from the [dir] tables of the
+// applied configuration file and the configuration files in directories below it. The
+// nearest settings win: a nested file overrides the files above it, and the tables of a
+// file override its top-level settings.
+type dirConfigs struct {
+	fsys FS
+	// root is the directory of the applied configuration file. Without one it is "", and
+	// configuration files in any directory apply.
+	root string
+	// rootConfig is the applied configuration file; its top-level settings are already
+	// part of base.
+	rootConfig *config
+	base       quotedconv.Options
+	// pinned is the set of flags given on the command line or in the environment.
+	pinned map[string]bool
+	// newConverter returns the converter for the options of a directory.
+	newConverter func(quotedconv.Options) (*quotedconv.Converter, error)
+
+	mu sync.Mutex
+	// configs holds the configuration file of every directory looked at, nil for
+	// directories without one.
+	configs map[string]*config
+	// converters holds a converter for every distinct list of overrides.
+	converters map[string]*quotedconv.Converter
+}
+
+// dirLayer is a list of overrides from a configuration file.
+type dirLayer struct {
+	cfg *config
+	// table is the pattern of the table the entries are from, or "" for the top-level
+	// entries of a nested file.
+	table   string
+	entries []configEntry
+}
+
+func newDirConfigs(fsys FS, opts Options, newConverter func(quotedconv.Options) (*quotedconv.Converter, error)) (*dirConfigs, error) {
+	d := &dirConfigs{
+		fsys:         fsys,
+		root:         "",
+		rootConfig:   nil,
+		base:         opts.Options,
+		pinned:       map[string]bool{},
+		newConverter: newConverter,
+		mu:           sync.Mutex{},
+		configs:      map[string]*config{},
+		converters:   map[string]*quotedconv.Converter{},
+	}
+
+	for _, name := range opts.Pinned {
+		d.pinned[name] = true
+	}
+
+	if opts.Config == "" {
+		return d, nil
+	}
+
+	cfg, err := loadConfig(fsys, opts.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, t := range cfg.dirs {
+		if err := checkDirSettings(cfg, t.entries); err != nil {
+			return nil, err
+		}
+	}
+
+	root, err := filepath.Abs(filepath.Dir(opts.Config))
+	if err != nil {
+		return nil, fmt.Errorf("resolve path: %w", err)
+	}
+
+	d.root, d.rootConfig = root, cfg
+
+	return d, nil
+}
+
+// converter returns the converter for filename and a key identifying its overrides, or
+// a nil converter if there are none.
+func (d *dirConfigs) converter(filename string) (*quotedconv.Converter, string, error) {
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		return nil, "", fmt.Errorf("resolve path: %w", err)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	layers, err := d.layers(filepath.Dir(abs))
+	if err != nil || len(layers) == 0 {
+		return nil, "", err
+	}
+
+	names := make([]string, 0, len(layers))
+	for _, l := range layers {
+		names = append(names, l.cfg.path+"#"+l.table)
+	}
+
+	key := strings.Join(names, "\x00")
+
+	if c, ok := d.converters[key]; ok {
+		return c, key, nil
+	}
+
+	opts := d.base
+	set := conversionFlagSet(&opts)
+
+	for _, l := range layers {
+		if err := l.cfg.apply(set, l.entries, d.pinned); err != nil {
+			return nil, "", err
+		}
+	}
+
+	c, err := d.newConverter(opts)
+	if err != nil {
+		return nil, "", fmt.Errorf("%s: %w", layers[len(layers)-1].cfg.path, err)
+	}
+
+	d.converters[key] = c
+
+	return c, key, nil
+}
+
+// layers returns the overrides that apply to the files of dir, outermost first.
+func (d *dirConfigs) layers(dir string) ([]dirLayer, error) {
+	// dirs holds dir and the directories above it, up to root, innermost first.
+	var dirs []string
+
+	for cur := dir; cur != d.root; {
+		dirs = append(dirs, cur)
+
+		parent := filepath.Dir(cur)
+		if parent == cur {
+			if d.root != "" {
+				// dir is not below root.
+				return nil, nil
+			}
+
+			break
+		}
+
+		cur = parent
+	}
+
+	var layers []dirLayer
+
+	if d.rootConfig != nil {
+		layers = d.rootConfig.tableLayers(d.root, dir)
+	}
+
+	for i := len(dirs) - 1; i >= 0; i-- {
+		cfg, err := d.load(dirs[i])
+		if err != nil {
+			return nil, err
+		}
+
+		if cfg == nil {
+			continue
+		}
+
+		if len(cfg.entries) > 0 {
+			layers = append(layers, dirLayer{cfg: cfg, table: "", entries: cfg.entries})
+		}
+
+		layers = append(layers, cfg.tableLayers(dirs[i], dir)...)
+	}
+
+	return layers, nil
+}
+
+// load returns the configuration file in dir, or nil if there is none. d.mu must be held.
+func (d *dirConfigs) load(dir string) (*config, error) {
+	if cfg, ok := d.configs[dir]; ok {
+		return cfg, nil
+	}
+
+	path := filepath.Join(dir, configName)
+
+	data, err := d.fsys.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		d.configs[dir] = nil
+
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("read config: %w", err)
+	}
+
+	cfg, err := parseConfig(path, string(data))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkDirSettings(cfg, cfg.entries); err != nil {
+		return nil, err
+	}
+
+	for _, t := range cfg.dirs {
+		if err := checkDirSettings(cfg, t.entries); err != nil {
+			return nil, err
+		}
+	}
+
+	d.configs[dir] = cfg
+
+	return cfg, nil
+}
+
+// tableLayers returns the tables of cfg, found in the directory base, that cover dir:
+// those whose pattern matches dir or a directory above it, relative to base.
+func (cfg *config) tableLayers(base, dir string) []dirLayer {
+	rel, err := filepath.Rel(base, dir)
+	if err != nil || rel == "." {
+		return nil
+	}
+
+	elements := strings.Split(filepath.ToSlash(rel), "/")
+
+	var layers []dirLayer
+
+	for _, t := range cfg.dirs {
+		for i := 1; i <= len(elements); i++ {
+			if matchGlob(t.pattern, strings.Join(elements[:i], "/")) {
+				layers = append(layers, dirLayer{cfg: cfg, table: t.pattern, entries: t.entries})
+
+				break
+			}
+		}
+	}
+
+	return layers
+}
+
+// conversionFlagSet returns a flag set of the conversion flags bound to opts.
+func conversionFlagSet(opts *quotedconv.Options) *flag.FlagSet {
+	set := flag.NewFlagSet("", flag.ContinueOnError)
+	set.SetOutput(io.Discard)
+	conversionFlags(set, opts)
+
+	return set
+}
+
+// checkDirSettings reports an error for entries of cfg that cannot be set per
+// directory, or whose value is invalid.
+func checkDirSettings(cfg *config, entries []configEntry) error {
+	opts := quotedconv.DefaultOptions()
+	set := conversionFlagSet(&opts)
+
+	for _, e := range entries {
+		if set.Lookup(e.key) == nil {
+			return fmt.Errorf("%s:%d: %q cannot be set per directory", cfg.path, e.line, e.key)
+		}
+	}
+
+	return cfg.apply(set, entries, nil)
+}
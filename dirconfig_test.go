@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/otakakot/quotedconv/quotedconv"
+)
+
+func TestShorthandFlagsOverrideDirTables(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		configName: "[dir.\"sub\"]\nquotes = \"skip\"\n",
+		"sub/a.go": "package sub\n\nvar A = `say \"hi\"`\n",
+	})
+
+	opts := defaultOptions()
+
+	fs := flag.NewFlagSet("", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	conversionFlags(fs, &opts.Options)
+	fs.Bool("escape-quotes", false, "")
+
+	if err := fs.Parse([]string{"-escape-quotes"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// As main does for -escape-quotes.
+	opts.QuotePolicy = quotedconv.QuotePolicyEscape
+	opts.Pinned = slices.Sorted(maps.Keys(pinnedFlags(fs)))
+	opts.Config = filepath.Join(dir, configName)
+
+	p, err := NewProcessor(opts, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := p.ProcessPaths(context.Background(), []string{dir}, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := os.ReadFile(filepath.Join(dir, "sub", "a.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "package sub\n\nvar A = \"say \\\"hi\\\"\"\n"; string(got) != want {
+		t.Errorf("sub/a.go = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/otakakot/quotedconv/quotedconv"
+)
+
+// envPrefix starts the names of the environment variables that set flags.
+const envPrefix = "QUOTEDCONV_"
+
+// envName returns the environment variable that sets the flag name: QUOTEDCONV_ and the
+// name in upper case, with dashes replaced by underscores.
+func envName(name string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+// applyEnv sets the flags of fs that were not given on the command line from the
+// QUOTEDCONV_ variables of environ, a list of KEY=value pairs as returned by os.Environ.
+// Empty variables are ignored, and variables naming no flag are reported to logger and
+// ignored, as a CI environment may carry stale ones. The values of -exclude, -rewrite
+// and the other repeatable flags that do not take comma-separated values are given one
+// per line.
+func applyEnv(fs *flag.FlagSet, environ []string, logger quotedconv.Logger) error {
+	set := pinnedFlags(fs)
+
+	names := map[string]string{}
+	fs.VisitAll(func(f *flag.Flag) {
+		names[envName(f.Name)] = f.Name
+	})
+
+	for _, kv := range slices.Sorted(slices.Values(environ)) {
+		key, value, _ := strings.Cut(kv, "=")
+		if !strings.HasPrefix(key, envPrefix) || value == "" {
+			continue
+		}
+
+		name, ok := names[key]
+		if !ok {
+			logger.Printf("Warning: ignoring %s, which names no flag", key)
+
+			continue
+		}
+
+		if set[name] {
+			continue
+		}
+
+		values := []string{value}
+
+		switch fs.Lookup(name).Value.(type) {
+		case *stringList, *rewriteRules:
+			values = nil
+
+			for _, line := range strings.Split(value, "\n") {
+				if line = strings.TrimSpace(line); line != "" {
+					values = append(values, line)
+				}
+			}
+		}
+
+		for _, v := range values {
+			if err := fs.Set(name, v); err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"log"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestApplyEnv(t *testing.T) {
+	fs := flag.NewFlagSet("", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	workers := fs.Int("workers", 0, "")
+	quotes := fs.String("quotes", "skip", "")
+
+	var excludes stringList
+	fs.Var(&excludes, "exclude", "")
+
+	if err := fs.Parse([]string{"-quotes=raw"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var logs bytes.Buffer
+
+	environ := []string{
+		"QUOTEDCONV_WORKERS=4",
+		"QUOTEDCONV_QUOTES=escape",
+		"QUOTEDCONV_EXCLUDE=gen/**\n  third_party/**\n",
+		"QUOTEDCONV_STALE=1",
+		"PATH=/bin",
+	}
+
+	if err := applyEnv(fs, environ, log.New(&logs, "", 0)); err != nil {
+		t.Fatal(err)
+	}
+
+	if *workers != 4 {
+		t.Errorf("workers = %d, want 4", *workers)
+	}
+
+	if *quotes != "raw" {
+		t.Errorf("quotes = %q, want the command line value raw", *quotes)
+	}
+
+	if want := []string{"gen/**", "third_party/**"}; !slices.Equal(excludes, want) {
+		t.Errorf("excludes = %q, want %q", excludes, want)
+	}
+
+	if !strings.Contains(logs.String(), "QUOTEDCONV_STALE") {
+		t.Errorf("unknown variable not reported: %q", logs.String())
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// matchGlob reports whether the slash-separated name matches pattern, a glob in which
+// "**" as a whole path element matches any number of elements, including none, and
+// other elements are matched by path.Match.
+func matchGlob(pattern, name string) bool {
+	return matchElements(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchElements(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := len(name); i >= 0; i-- {
+				if matchElements(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+
+			return false
+		}
+
+		if len(name) == 0 {
+			return false
+		}
+
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+
+		pattern, name = pattern[1:], name[1:]
+	}
+
+	return len(name) == 0
+}
+
+// validGlob reports whether every element of pattern is a valid path.Match pattern.
+func validGlob(pattern string) error {
+	for _, element := range strings.Split(pattern, "/") {
+		if _, err := path.Match(element, ""); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// excluded reports whether the file or directory at rel, a path relative to the root
+// it was found under, matches an -exclude pattern.
+func (p *Processor) excluded(rel string) bool {
+	rel = filepath.ToSlash(filepath.Clean(rel))
+
+	for _, pattern := range p.opts.Excludes {
+		if matchGlob(pattern, rel) {
+			return true
+		}
+	}
+
+	return false
+}
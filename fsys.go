@@ -0,0 +1,44 @@
+package main
+
+import (
+	"io/fs"
+	"os"
+)
+
+// FS is the file system the Processor reads, lists and writes source files through,
+// so it can run against an in-memory tree or a virtual file system. Names are paths
+// as given on the command line. Directories are listed with ReadDir rather than walked
+// with WalkDir, as the Processor reads them concurrently.
+type FS interface {
+	ReadFile(name string) ([]byte, error)
+	// WriteFile replaces the contents of name, creating it if necessary, and sets its
+	// permissions to perm.
+	WriteFile(name string, data []byte, perm fs.FileMode) error
+	Stat(name string) (fs.FileInfo, error)
+	ReadDir(name string) ([]fs.DirEntry, error)
+}
+
+// osFS is the FS of the operating system. Memory-mapped reads and durable writes are
+// only available on it.
+type osFS struct{}
+
+func (osFS) ReadFile(name string) ([]byte, error) {
+	return os.ReadFile(name)
+}
+
+func (osFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	if err := os.WriteFile(name, data, perm); err != nil {
+		return err
+	}
+
+	// os.WriteFile only applies perm to files it creates.
+	return os.Chmod(name, perm)
+}
+
+func (osFS) Stat(name string) (fs.FileInfo, error) {
+	return os.Stat(name)
+}
+
+func (osFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	return os.ReadDir(name)
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"io/fs"
+	"sync"
+	"testing"
+	"testing/fstest"
+)
+
+// memFS is an in-memory FS.
+type memFS struct {
+	mu    sync.RWMutex
+	files fstest.MapFS
+}
+
+func (m *memFS) ReadFile(name string) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.files.ReadFile(name)
+}
+
+func (m *memFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.files[name] = &fstest.MapFile{Data: data, Mode: perm}
+
+	return nil
+}
+
+func (m *memFS) Stat(name string) (fs.FileInfo, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.files.Stat(name)
+}
+
+func (m *memFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.files.ReadDir(name)
+}
+
+func TestProcessPathsInMemoryFS(t *testing.T) {
+	mem := &memFS{files: fstest.MapFS{
+		"src/a.go":          {Data: []byte("package src\n\nvar A = `a`\n"), Mode: 0o640},
+		"src/b.go":          {Data: []byte("package src\n\nvar B = \"b\"\n")},
+		"src/sub/c.go":      {Data: []byte("package sub\n\nvar C = `c`\n")},
+		"src/vendor/d.go":   {Data: []byte("package d\n\nvar D = `d`\n")},
+		"src/sub/notes.txt": {Data: []byte("`raw`\n")},
+	}}
+
+	opts := defaultOptions()
+	opts.FS = mem
+
+	p, err := NewProcessor(opts, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rep, err := p.ProcessPaths(context.Background(), []string{"src"}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rep.Processed != 3 {
+		t.Errorf("processed %d files, want 3", rep.Processed)
+	}
+
+	want := map[string]string{
+		"src/a.go":          "package src\n\nvar A = \"a\"\n",
+		"src/b.go":          "package src\n\nvar B = \"b\"\n",
+		"src/sub/c.go":      "package sub\n\nvar C = \"c\"\n",
+		"src/vendor/d.go":   "package d\n\nvar D = `d`\n",
+		"src/sub/notes.txt": "`raw`\n",
+	}
+
+	for name, content := range want {
+		if got := string(mem.files[name].Data); got != content {
+			t.Errorf("%s = %q, want %q", name, got, content)
+		}
+	}
+
+	if mode := mem.files["src/a.go"].Mode; mode != 0o640 {
+		t.Errorf("src/a.go mode = %v, want 0640", mode)
+	}
+}
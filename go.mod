@@ -1,3 +1,11 @@
 module github.com/otakakot/quotedconv
 
 go 1.24.2
+
+require (
+	github.com/golangci/plugin-module-register v0.1.2
+	golang.org/x/mod v0.29.0
+	golang.org/x/tools v0.38.0
+)
+
+require golang.org/x/sync v0.17.0 // indirect
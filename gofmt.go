@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/otakakot/quotedconv/internal/diff"
+	"github.com/otakakot/quotedconv/quotedconv"
+)
+
+// stdinName is the file name gofmt mode uses for standard input.
+const stdinName = "<standard input>"
+
+// gofmtOutput returns what gofmt mode prints to standard output for filename, given its
+// original source and its converted source with the changes made, which is nil when
+// the file does not change: the file name with -l, a diff with -d, and the source when
+// neither -l, -d nor -w is given. It returns nil outside gofmt mode.
+func (p *Processor) gofmtOutput(filename string, src, formatted []byte, changes []quotedconv.Change) []byte {
+	opts := p.opts
+
+	if !opts.Gofmt {
+		return nil
+	}
+
+	var out []byte
+
+	if formatted != nil && opts.List {
+		terminator := "\n"
+		if opts.Print0 {
+			terminator = "\x00"
+		}
+
+		out = append(out, filename+terminator...)
+	}
+
+	if formatted != nil && opts.Diff {
+		out = fmt.Appendf(out, "diff %s.orig %s\n", filename, filename)
+		out = append(out, annotatedDiff(filename, src, formatted, changes)...)
+	}
+
+	if !opts.List && !opts.Diff && !opts.Write {
+		if formatted == nil {
+			formatted = src
+		}
+
+		out = append(out, formatted...)
+	}
+
+	return out
+}
+
+// annotatedDiff returns a unified diff of the conversion of filename from src to
+// formatted, with the hunk headers naming the rule and detail of each change, and with
+// detected credentials redacted.
+func annotatedDiff(filename string, src, formatted []byte, changes []quotedconv.Change) string {
+	notes := map[int][]string{}
+	for _, c := range changes {
+		notes[c.Pos.Line] = append(notes[c.Pos.Line], fmt.Sprintf("%d:%d: %s: %s", c.Pos.Line, c.Pos.Column, c.Rule, c.Detail))
+	}
+
+	return redactText(diff.Annotated(filename+".orig", filename, src, formatted, notes), changes)
+}
+
+// FilterStdin converts the source read from r and writes the gofmt mode output to w.
+func (p *Processor) FilterStdin(ctx context.Context, r io.Reader, w io.Writer) error {
+	if p.opts.Write {
+		return errors.New("cannot use -w with standard input")
+	}
+
+	src, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("read standard input: %w", err)
+	}
+
+	result, err := p.converter.Convert(ctx, stdinName, src)
+	if err != nil && !errors.Is(err, quotedconv.ErrFileIgnored) {
+		return err
+	}
+
+	formatted := result.Source
+	if len(result.Changes) == 0 || !needsWrite(src, formatted) {
+		formatted = nil
+	}
+
+	if _, err := w.Write(p.gofmtOutput(stdinName, src, formatted, result.Changes)); err != nil {
+		return fmt.Errorf("write standard output: %w", err)
+	}
+
+	return nil
+}
+
+// outputSequencer writes the gofmt mode output of files in the order in which they
+// were queued. A file's output is written as soon as it and every file before it are
+// done, so output streams during long runs yet stays deterministic. A nil
+// outputSequencer discards everything.
+type outputSequencer struct {
+	mu       sync.Mutex
+	w        io.Writer
+	index    map[string]int
+	finished map[int][]byte
+	next     int
+	err      error
+}
+
+func newOutputSequencer(w io.Writer, files []string) *outputSequencer {
+	index := make(map[string]int, len(files))
+	for i, file := range files {
+		index[file] = i
+	}
+
+	return &outputSequencer{mu: sync.Mutex{}, w: w, index: index, finished: map[int][]byte{}, next: 0, err: nil}
+}
+
+// done records the output of file and writes all output that is now in order.
+func (s *outputSequencer) done(file string, output []byte) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i, ok := s.index[file]
+	if !ok {
+		return
+	}
+
+	s.finished[i] = output
+
+	for {
+		output, ok := s.finished[s.next]
+		if !ok {
+			return
+		}
+
+		s.write(output)
+		delete(s.finished, s.next)
+		s.next++
+	}
+}
+
+// close writes the output still held back because an earlier file never finished, for
+// example after cancellation, and returns the first write error.
+func (s *outputSequencer) close() error {
+	if s == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, i := range slices.Sorted(maps.Keys(s.finished)) {
+		s.write(s.finished[i])
+	}
+
+	clear(s.finished)
+
+	return s.err
+}
+
+func (s *outputSequencer) write(output []byte) {
+	if s.err != nil || len(output) == 0 {
+		return
+	}
+
+	_, s.err = s.w.Write(output)
+}
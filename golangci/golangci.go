@@ -0,0 +1,81 @@
+// Package golangci integrates the quotedconv analyzer with golangci-lint, which runs
+// it alongside other linters with shared package loading, caching and path filtering.
+package golangci
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/golangci/plugin-module-register/register"
+	"golang.org/x/tools/go/analysis"
+
+	"github.com/otakakot/quotedconv/analyzer"
+	"github.com/otakakot/quotedconv/quotedconv"
+)
+
+func init() {
+	register.Plugin("quotedconv", NewPlugin)
+}
+
+// New returns the quotedconv analyzer configured by settings, the linter settings of
+// the golangci-lint configuration. Settings are the fields of quotedconv.Options,
+// matched case-insensitively, such as toRaw or keepSQL; unset fields keep their
+// defaults and unknown fields are an error.
+func New(settings any) ([]*analysis.Analyzer, error) {
+	opts, err := decodeSettings(settings)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := quotedconv.New(quotedconv.WithOptions(opts), quotedconv.WithLogger(nil))
+	if err != nil {
+		return nil, fmt.Errorf("invalid settings: %w", err)
+	}
+
+	return []*analysis.Analyzer{analyzer.New(c)}, nil
+}
+
+// Plugin implements the LinterPlugin interface of golangci-lint's module plugin system.
+type Plugin struct {
+	settings any
+}
+
+// NewPlugin returns the module plugin for settings. It is registered as "quotedconv".
+func NewPlugin(settings any) (register.LinterPlugin, error) {
+	if _, err := New(settings); err != nil {
+		return nil, err
+	}
+
+	return &Plugin{settings: settings}, nil
+}
+
+func (p *Plugin) BuildAnalyzers() ([]*analysis.Analyzer, error) {
+	return New(p.settings)
+}
+
+// GetLoadMode returns the syntax load mode: the analyzer needs no type information.
+func (p *Plugin) GetLoadMode() string {
+	return register.LoadModeSyntax
+}
+
+func decodeSettings(settings any) (quotedconv.Options, error) {
+	opts := quotedconv.DefaultOptions()
+	if settings == nil {
+		return opts, nil
+	}
+
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return opts, fmt.Errorf("encode settings: %w", err)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(&opts); err != nil {
+		return opts, fmt.Errorf("decode settings: %w", err)
+	}
+
+	return opts, nil
+}
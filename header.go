@@ -0,0 +1,92 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"path"
+	"regexp"
+)
+
+// errHeaderSkipped reports a file whose header matches a -skip-header pattern.
+var errHeaderSkipped = errors.New("header matches skip pattern")
+
+func compileSkipHeaders(patterns []string) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("compile skip header %q: %w", pattern, err)
+		}
+
+		compiled = append(compiled, re)
+	}
+
+	return compiled, nil
+}
+
+// packageSelected reports whether the package clause of src matches one of
+// opts.Packages, or whether no packages are selected.
+func (p *Processor) packageSelected(src []byte) bool {
+	if len(p.opts.Packages) == 0 {
+		return true
+	}
+
+	file, err := parser.ParseFile(token.NewFileSet(), "", src, parser.PackageClauseOnly)
+	if err != nil {
+		// The full parse reports the error.
+		return true
+	}
+
+	for _, pattern := range p.opts.Packages {
+		if ok, _ := path.Match(pattern, file.Name.Name); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// packageOf returns the import path of the package of filename where a package pattern
+// resolved it, and the name in the package clause of src otherwise.
+func (p *Processor) packageOf(filename string, src []byte) string {
+	if importPath, ok := p.importPaths.Load(filename); ok {
+		return importPath.(string)
+	}
+
+	file, err := parser.ParseFile(token.NewFileSet(), "", src, parser.PackageClauseOnly)
+	if err != nil {
+		return ""
+	}
+
+	return file.Name.Name
+}
+
+// matchSkipHeader returns the first pattern matching the header of src, which is
+// everything before the package clause: license banners, "mirrored from" notes and
+// similar markers of sources copied in-tree.
+func (p *Processor) matchSkipHeader(src []byte) (*regexp.Regexp, bool) {
+	if len(p.skipHeaders) == 0 {
+		return nil, false
+	}
+
+	fset := token.NewFileSet()
+
+	file, err := parser.ParseFile(fset, "", src, parser.PackageClauseOnly)
+	if err != nil {
+		// The full parse reports the error.
+		return nil, false
+	}
+
+	header := src[:fset.Position(file.Package).Offset]
+
+	for _, re := range p.skipHeaders {
+		if re.Match(header) {
+			return re, true
+		}
+	}
+
+	return nil, false
+}
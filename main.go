@@ -5,40 +5,295 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"flag"
 	"fmt"
-	"go/ast"
 	"go/format"
-	"go/parser"
-	"go/printer"
-	"go/token"
+	"io"
 	"io/fs"
 	"log"
+	"maps"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"regexp"
 	"runtime"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
+	"syscall"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/otakakot/quotedconv/internal/diff"
+	"github.com/otakakot/quotedconv/quotedconv"
 )
 
 func main() {
-	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer cancel()
 
-	root := getTargetPath()
+	opts := defaultOptions()
+
+	args := os.Args[1:]
+
+	if len(args) > 0 && args[0] == "self-update" {
+		fs := flag.NewFlagSet("self-update", flag.ExitOnError)
+		force := fs.Bool("force", false, "install the latest release even if it is older than this build or this build's version is unknown")
+		fs.Parse(args[1:])
+
+		if err := selfUpdate(ctx, *force); err != nil {
+			panic("Error: " + err.Error())
+		}
+
+		return
+	}
+
+	serve := len(args) > 0 && args[0] == "serve"
+	if serve {
+		args = args[1:]
+	}
+
+	listen := ":8080"
+	if serve {
+		flag.StringVar(&listen, "listen", listen, "address serve mode listens on")
+	}
+
+	conversionFlags(flag.CommandLine, &opts.Options)
+	escapeQuotes := flag.Bool("escape-quotes", false, "shorthand for -quotes=escape: convert raw literals containing double quotes, escaping the quotes")
+	ascii := flag.Bool("ascii", false, "shorthand for -escaping=ascii: escape non-ASCII runes in converted literals as \\u sequences")
+	keepUnicode := flag.Bool("keep-unicode", false, "shorthand for -escaping=unicode, the default: keep printable runes as they are")
+	flag.Var(&opts.Lines, "lines", "only convert literals within this inclusive line range, e.g. 10:20 (either side may be omitted)")
+	flag.BoolVar(&opts.ShowLiterals, "show-literals", false, "print each converted literal with its before and after text")
+	flag.BoolVar(&opts.Verbose, "v", false, "verbose: show each change with its surrounding source")
+	flag.BoolVar(&opts.ShowContent, "show-content", false, "include literal contents in diagnostics, reports and diffs instead of only positions and lengths")
+	flag.BoolVar(&opts.Census, "census", false, "count convertible literals by the type they are used as (loads packages with type information)")
+	flag.BoolVar(&opts.Stat, "stat", false, "print a diffstat of the rewritten files")
+	flag.Var(&opts.Format, "format", "report format: text, json, markdown, quickfix or lsp")
+	flag.IntVar(&opts.FormatVersion, "format-version", reportSchemaVersion, "schema version of JSON output")
+	flag.Int64Var(&opts.MmapThreshold, "mmap-threshold", 0, "memory-map files of at least this many bytes instead of reading them (0 disables)")
+	flag.DurationVar(&opts.FileTimeout, "file-timeout", 0, "maximum time spent on a single file before it is skipped (0 means no limit)")
+	flag.StringVar(&opts.NotifyURL, "notify-url", "", "POST the run summary as JSON to this URL when the run finishes")
+	flag.BoolVar(&opts.NotifySlack, "notify-slack", false, "send a Slack-compatible payload to -notify-url")
+	flag.StringVar(&opts.AuditLog, "audit-log", "", "append an audit record with content hashes of every modified file to this file")
+	flag.Var((*newerThan)(&opts.NewerThan), "newer-than", "only process files modified after this time (RFC 3339 timestamp, date, or duration such as 24h)")
+	flag.Var((*byteSize)(&opts.MinSize), "min-size", "only process files of at least this size, e.g. 512 or 4KiB")
+	flag.Var((*byteSize)(&opts.MaxSize), "max-size", "only process files of at most this size, e.g. 64KiB or 1MiB")
+	flag.IntVar(&opts.Workers, "workers", 0, "number of files converted at once (0 means the number of CPUs)")
+	flag.IntVar(&opts.WalkWorkers, "walk-workers", opts.WalkWorkers, "maximum number of directories read concurrently during the walk")
+	flag.BoolVar(&opts.Durable, "durable", false, "write through a synced temporary file renamed over the original, then sync its directory")
+	flag.Var((*fileMode)(&opts.FileMode), "file-mode", "permissions of written files in octal, e.g. 0640 (default: preserve the original permissions)")
+	flag.IntVar(&opts.MaxWriteConcurrency, "max-write-concurrency", 0, "maximum number of files written at once (0 means no limit)")
+	flag.Var((*commaList)(&opts.Packages), "packages", "comma-separated package names or globs; only files whose package clause matches are processed (repeatable)")
+	flag.BoolVar(&opts.SkipTests, "skip-tests", false, "do not process _test.go files")
+	flag.BoolVar(&opts.OnlyTests, "only-tests", false, "only process _test.go files")
+	flag.Var((*commaList)(&opts.SkipTypes), "skip-types", "comma-separated named string types, as package.Type, whose literals are never converted; loads type information (repeatable)")
+	flag.BoolVar(&opts.IncludeVendor, "include-vendor", false, "also process vendor, node_modules and version control directories")
+	flag.BoolVar(&opts.IncludeTestdata, "include-testdata", false, "also process testdata directories")
+	flag.BoolVar(&opts.IncludeHidden, "include-hidden", false, "also process hidden directories (names starting with a dot)")
+	flag.Var((*stringList)(&opts.Excludes), "exclude", "skip files and directories matching this glob relative to the target, where ** matches any number of directories (repeatable)")
+	flag.Var((*stringList)(&opts.IncludeHiddenPatterns), "include-hidden-dir", "process hidden directories whose name matches this glob (repeatable)")
+	flag.IntVar(&opts.MaxNesting, "max-nesting", opts.MaxNesting, "skip files whose brackets nest deeper than this (0 disables the limit)")
+	flag.Var((*stringList)(&opts.SkipHeaders), "skip-header", "skip files whose header before the package clause matches this regular expression (repeatable)")
+	flag.BoolVar(&opts.Watch, "watch", false, "keep running and convert files as they change")
+	flag.DurationVar(&opts.WatchInterval, "watch-interval", opts.WatchInterval, "how often watch mode polls for changes")
+	flag.DurationVar(&opts.WatchDebounce, "watch-debounce", opts.WatchDebounce, "quiet period after the last change before watch mode runs a batch")
+	flag.BoolVar(&opts.Strict, "strict", false, "list convertible literals left alone by heuristics or directives and exit with status 1 if there are any")
+	flag.StringVar(&opts.Overlay, "overlay", "", "JSON file mapping file paths to contents that replace them, such as unsaved editor buffers")
+	flag.BoolVar(&opts.Check, "check", false, "write nothing and exit with status 1 if any file would be changed")
+	flag.BoolVar(&opts.DryRun, "n", false, "dry run: report the changes without writing any file")
+	flag.BoolVar(&opts.DryRun, "dry-run", false, "dry run: report the changes without writing any file")
+	flag.BoolVar(&opts.List, "l", false, "gofmt mode: list files whose literals would be converted")
+	flag.BoolVar(&opts.Print0, "print0", false, "with -l, terminate file names with NUL instead of newline, for xargs -0")
+	flag.BoolVar(&opts.Diff, "d", false, "gofmt mode: print diffs instead of rewriting files")
+	flag.BoolVar(&opts.Txtar, "txtar", false, "read a txtar archive from standard input or the file argument and write it with its Go files converted")
+	flag.BoolVar(&opts.Write, "w", false, "gofmt mode: write the result to the source file instead of standard output")
+	githubSummary := flag.Bool("github-summary", true, "append a Markdown summary to $GITHUB_STEP_SUMMARY when it is set")
+	configPath := flag.String("config", "", "configuration file to apply instead of the nearest "+configName+" above the target")
+	flag.Usage = usage
+	flag.CommandLine.Parse(args)
+
+	if err := applyEnv(flag.CommandLine, os.Environ(), log.Default()); err != nil {
+		panic("Error: " + err.Error())
+	}
+
+	opts.Pinned = slices.Sorted(maps.Keys(pinnedFlags(flag.CommandLine)))
 
-	if err := processPath(ctx, root, runtime.NumCPU()); err != nil && !errors.Is(err, context.Canceled) {
+	configFile, err := applyConfigFile(osFS{}, flag.CommandLine, *configPath)
+	if err != nil {
 		panic("Error: " + err.Error())
 	}
+
+	if configFile != "" && opts.Verbose {
+		log.Printf("Using configuration %s", configFile)
+	}
+
+	opts.Config = configFile
+
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "l", "d", "w":
+			opts.Gofmt = true
+		}
+	})
+
+	// "-" filters standard input to standard output, as gofmt does without arguments.
+	stdin := flag.NArg() == 1 && flag.Arg(0) == "-"
+	if stdin {
+		opts.Gofmt = true
+	}
+
+	if *escapeQuotes {
+		if opts.QuotePolicy != quotedconv.QuotePolicySkip && opts.QuotePolicy != quotedconv.QuotePolicyEscape {
+			panic(fmt.Sprintf("Error: -escape-quotes conflicts with -quotes=%s", opts.QuotePolicy))
+		}
+
+		opts.QuotePolicy = quotedconv.QuotePolicyEscape
+	}
+
+	switch {
+	case *ascii && *keepUnicode:
+		panic("Error: -ascii conflicts with -keep-unicode")
+	case *ascii:
+		opts.Escaping = quotedconv.EscapingASCII
+	case *keepUnicode:
+		opts.Escaping = quotedconv.EscapingUnicode
+	}
+
+	if *githubSummary {
+		opts.GitHubSummaryPath = os.Getenv("GITHUB_STEP_SUMMARY")
+	}
+
+	// Serve mode watches its target, if any, for as long as it runs.
+	opts.Watch = opts.Watch || serve
+
+	processor, err := NewProcessor(opts, log.Default())
+	if err != nil {
+		panic("Error: " + err.Error())
+	}
+
+	if opts.Txtar {
+		if err := filterTxtar(ctx, processor); err != nil {
+			panic("Error: " + err.Error())
+		}
+
+		return
+	}
+
+	if serve {
+		if err := processor.Serve(ctx, listen, flag.Args(), opts.Workers, os.Stdout); err != nil {
+			panic("Error: " + err.Error())
+		}
+
+		return
+	}
+
+	if stdin || (opts.Gofmt && flag.NArg() == 0) {
+		if err := processor.FilterStdin(ctx, os.Stdin, os.Stdout); err != nil {
+			panic("Error: " + err.Error())
+		}
+
+		return
+	}
+
+	roots := getTargetPaths()
+
+	if opts.Watch {
+		if err := processor.Watch(ctx, roots, opts.Workers, os.Stdout); err != nil {
+			panic("Error: " + err.Error())
+		}
+
+		return
+	}
+
+	rep, err := processor.ProcessPaths(ctx, roots, opts.Workers)
+	if err := processor.publishReport(ctx, os.Stdout, rep, err); err != nil {
+		panic("Error: " + err.Error())
+	}
+
+	if err != nil && !errors.Is(err, context.Canceled) {
+		panic("Error: " + err.Error())
+	}
+
+	if opts.Check && rep != nil && len(rep.Files) > 0 {
+		log.Printf("Check failed: %d files would be changed", len(rep.Files))
+		os.Exit(1)
+	}
+
+	if opts.Strict && rep != nil && len(rep.Suppressed) > 0 {
+		log.Printf("Strict mode failed: %d convertible literals were suppressed", len(rep.Suppressed))
+		os.Exit(1)
+	}
+}
+
+// conversionFlags defines on fs the flags that set conversion options in opts, with
+// the current values of opts as their defaults. Only these can be set per directory.
+func conversionFlags(fs *flag.FlagSet, opts *quotedconv.Options) {
+	fs.Var(&opts.QuotePolicy, "quotes", "policy for literals containing double quotes: skip, escape or raw")
+	fs.BoolVar(&opts.EscapeBackslashes, "escape-backslashes", opts.EscapeBackslashes, "also convert raw literals containing backslashes, escaping them")
+	fs.Var(&opts.Invisible, "invisible", "policy for raw literals containing invisible characters such as bidirectional controls: escape, skip or error")
+	fs.Var(&opts.ControlChars, "control-chars", "policy for raw literals containing control characters such as tabs and carriage returns: escape, skip or error")
+	fs.BoolVar(&opts.NormalizeEscapes, "normalize-escapes", opts.NormalizeEscapes, "also re-quote existing interpreted literals according to -escaping")
+	fs.Var(&opts.Escaping, "escaping", "how runes are represented in converted literals: unicode, ascii or graphic")
+	fs.BoolVar(&opts.ToRaw, "to-raw", opts.ToRaw, "convert interpreted literals whose only escapes are \\\" and \\\\ to raw literals instead")
+	fs.BoolVar(&opts.Canonical, "canonical", opts.Canonical, "convert every literal to whichever form needs fewer escapes, raw or interpreted")
+	fs.Var(&opts.Scope, "scope", "comma-separated syntactic contexts whose literals are converted: all, or any of const, var, composite, callarg and return")
+	fs.BoolVar(&opts.RawTags, "raw-tags", opts.RawTags, "rewrite double-quoted struct tags to the conventional raw form")
+	fs.BoolVar(&opts.RequireEnable, "require-enable", opts.RequireEnable, "only process files containing a //quotedconv:enable directive")
+	fs.BoolVar(&opts.PatternCalls, "pattern-calls", opts.PatternCalls, "leave literals passed to regexp and template constructors untouched")
+	fs.IntVar(&opts.ReadabilityCap, "readability-cap", opts.ReadabilityCap, "never convert single-line raw literals longer than this many characters if escapes would be added (0 disables)")
+	fs.IntVar(&opts.MinLen, "min-len", opts.MinLen, "only convert raw literals with at least this many characters")
+	fs.IntVar(&opts.MaxLen, "max-len", opts.MaxLen, "only convert raw literals with at most this many characters (0 means no limit)")
+	fs.BoolVar(&opts.KeepSQL, "keep-sql", opts.KeepSQL, "leave raw literals that look like SQL statements untouched")
+	fs.BoolVar(&opts.KeepJSON, "keep-json", opts.KeepJSON, "leave raw literals that look like JSON objects or arrays untouched")
+	fs.BoolVar(&opts.KeepPaths, "keep-paths", opts.KeepPaths, "leave raw literals that look like URLs or filesystem paths untouched")
+	fs.StringVar(&opts.NamePattern, "name-pattern", opts.NamePattern, "only convert literals assigned to constants or variables whose name matches this regular expression")
+	fs.StringVar(&opts.SkipNamePattern, "skip-name-pattern", opts.SkipNamePattern, "never convert literals assigned to constants or variables whose name matches this regular expression")
+	fs.Var((*commaList)(&opts.SkipCalls), "skip-calls", "comma-separated functions, as package.Function, whose string arguments are never converted (repeatable)")
+	fs.Var((*rewriteRules)(&opts.Rewrites), "rewrite", "rewrite the content of converted literals, given as REGEX=>REPLACEMENT (repeatable)")
+}
+
+// usage prints the flags and where else they can be set.
+func usage() {
+	out := flag.CommandLine.Output()
+
+	fmt.Fprintf(out, "Usage of %s:\n", os.Args[0])
+	flag.PrintDefaults()
+	fmt.Fprintf(out, "\nEvery flag can also be set with a %s<FLAG> environment variable, e.g.\n", envPrefix)
+	fmt.Fprintf(out, "%s=4, or in a %s file. Flags on the command line take precedence\n", envName("workers"), configName)
+	fmt.Fprintf(out, "over environment variables, which take precedence over configuration files.\n")
+}
+
+// filterTxtar runs processor.FilterTxtar on the archive named by the only argument, or
+// on standard input without one or with "-".
+func filterTxtar(ctx context.Context, processor *Processor) error {
+	if flag.NArg() > 1 {
+		return errors.New("-txtar takes at most one archive")
+	}
+
+	in := io.Reader(os.Stdin)
+
+	if name := flag.Arg(0); name != "" && name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return fmt.Errorf("open archive: %w", err)
+		}
+		defer f.Close()
+
+		in = f
+	}
+
+	return processor.FilterTxtar(ctx, in, os.Stdout)
 }
 
-func getTargetPath() string {
-	if len(os.Args) > 1 {
-		return os.Args[1]
+func getTargetPaths() []string {
+	if flag.NArg() > 0 {
+		return flag.Args()
 	}
 
 	cwd, err := os.Getwd()
@@ -46,183 +301,799 @@ func getTargetPath() string {
 		panic("Failed to get current directory. Error: " + err.Error())
 	}
 
-	return cwd
+	return []string{cwd}
+}
+
+// Processor converts files according to a fixed set of options. Everything derived
+// from the options is prepared once by NewProcessor and never mutated afterwards, so a
+// single Processor may be shared by any number of goroutines.
+type Processor struct {
+	opts      Options
+	converter *quotedconv.Converter
+	// logger receives progress messages and warnings.
+	logger quotedconv.Logger
+	// skipTypes is the set of opts.SkipTypes.
+	skipTypes map[string]bool
+	// skipHeaders are the compiled opts.SkipHeaders.
+	skipHeaders []*regexp.Regexp
+	cache       *decisionCache
+	tracer      *tracer
+	// fsys is the file system source files are read from and written to.
+	fsys FS
+	// dirs resolves the per-directory configuration of files.
+	dirs *dirConfigs
+	// overlay is the loaded opts.Overlay, keyed by canonical path; nil without one.
+	overlay map[string][]byte
+	// stdout receives the gofmt mode output.
+	stdout io.Writer
+	// writeSem limits concurrent writes to opts.MaxWriteConcurrency; nil means no limit.
+	writeSem chan struct{}
+	// importPaths maps the files resolved from package patterns to the import paths of
+	// their packages.
+	importPaths *sync.Map
 }
 
-func processPath(ctx context.Context, path string, numWorkers int) error {
-	info, err := os.Stat(path)
+// NewProcessor returns a Processor for opts that reports progress and warnings to
+// logger; a nil logger discards them.
+func NewProcessor(opts Options, logger quotedconv.Logger) (*Processor, error) {
+	if err := opts.validate(); err != nil {
+		return nil, fmt.Errorf("invalid options: %w", err)
+	}
+
+	if logger == nil {
+		logger = log.New(io.Discard, "", 0)
+	}
+
+	tracer := newTracerFromEnv(logger)
+
+	newConverter := func(convOpts quotedconv.Options) (*quotedconv.Converter, error) {
+		convOpts.ReportSuppressed = opts.Strict
+
+		if tracer != nil {
+			convOpts.Trace = tracer.phase
+		}
+
+		return quotedconv.New(quotedconv.WithOptions(convOpts), quotedconv.WithLogger(logger))
+	}
+
+	converter, err := newConverter(opts.Options)
 	if err != nil {
-		return fmt.Errorf("stat path: %w", err)
+		return nil, err
 	}
 
-	if info.IsDir() {
-		files := []string{}
+	skipHeaders, err := compileSkipHeaders(opts.SkipHeaders)
+	if err != nil {
+		return nil, fmt.Errorf("invalid options: %w", err)
+	}
 
-		if err = filepath.WalkDir(path, func(pathStr string, dir fs.DirEntry, err error) error {
+	skipTypes := make(map[string]bool, len(opts.SkipTypes))
+	for _, typ := range opts.SkipTypes {
+		skipTypes[typ] = true
+	}
+
+	var cache *decisionCache
+	if opts.Watch {
+		cache = newDecisionCache(decisionCacheSize)
+	}
+
+	var (
+		fsys    FS = osFS{}
+		overlay map[string][]byte
+	)
+
+	if opts.FS != nil {
+		fsys = opts.FS
+	}
+
+	if opts.Overlay != "" {
+		if overlay, err = loadOverlay(fsys, opts.Overlay); err != nil {
+			return nil, fmt.Errorf("invalid options: %w", err)
+		}
+
+		fsys = newOverlayFS(fsys, overlay)
+	}
+
+	dirs, err := newDirConfigs(fsys, opts, newConverter)
+	if err != nil {
+		return nil, fmt.Errorf("invalid options: %w", err)
+	}
+
+	var writeSem chan struct{}
+	if opts.MaxWriteConcurrency > 0 {
+		writeSem = make(chan struct{}, opts.MaxWriteConcurrency)
+	}
+
+	return &Processor{opts: opts, converter: converter, logger: logger, skipTypes: skipTypes, skipHeaders: skipHeaders, cache: cache, tracer: tracer, fsys: fsys, dirs: dirs, overlay: overlay, stdout: os.Stdout, writeSem: writeSem, importPaths: &sync.Map{}}, nil
+}
+
+func (p *Processor) ProcessPath(ctx context.Context, path string, numWorkers int) (*report, error) {
+	return p.ProcessPaths(ctx, []string{path}, numWorkers)
+}
+
+// ProcessPaths processes every Go file in paths, each a file or a directory, through a
+// single worker pool and returns one combined report. Files reachable through several
+// paths are processed once.
+func (p *Processor) ProcessPaths(ctx context.Context, paths []string, numWorkers int) (*report, error) {
+	ctx, span := p.tracer.start(ctx, "run", "paths", strings.Join(paths, " "))
+	defer p.tracer.flush(ctx)
+	defer span.finish()
+
+	var census map[string]int
+
+	if p.opts.Census {
+		census = map[string]int{}
+
+		for _, path := range paths {
+			counts, err := p.literalCensus(ctx, path)
 			if err != nil {
-				return fmt.Errorf("walking directory: %w", err)
+				span.fail(err)
+
+				return nil, fmt.Errorf("literal census: %w", err)
 			}
-			if dir.IsDir() && dir.Name() == "vendor" {
-				return filepath.SkipDir
+
+			for name, n := range counts {
+				census[name] += n
 			}
+		}
+	}
+
+	var (
+		rep *report
+		err error
+	)
+
+	if len(paths) == 1 && !isPackagePattern(p.fsys, paths[0]) {
+		rep, err = p.processPath(ctx, paths[0], numWorkers)
+	} else {
+		started := time.Now()
+
+		var files []string
+		if files, err = p.collectFiles(ctx, paths); err == nil {
+			rep, err = p.processFiles(ctx, files, numWorkers, started)
+		}
+	}
+
+	span.fail(err)
 
-			if dir.IsDir() || !strings.HasSuffix(pathStr, ".go") {
-				return nil
+	if rep != nil {
+		rep.LiteralTypes = census
+	}
+
+	return rep, err
+}
+
+func (p *Processor) processPath(ctx context.Context, path string, numWorkers int) (*report, error) {
+	opts := p.opts
+	started := time.Now()
+
+	info, err := p.fsys.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("stat path: %w", err)
+	}
+
+	if info.IsDir() {
+		files, err := p.collectFiles(ctx, []string{path})
+		if err != nil {
+			return nil, err
+		}
+
+		return p.processFiles(ctx, files, numWorkers, started)
+	}
+
+	files, err := p.collectFiles(ctx, []string{path})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(files) == 0 {
+		return newReport(opts, started, 0, nil, nil, &collectorError{}), nil
+	}
+
+	ctx, err = p.withTypedSkips(ctx, []string{path})
+	if err != nil {
+		return nil, fmt.Errorf("skip types: %w", err)
+	}
+
+	result, err := p.fixFileWithTimeout(ctx, path)
+	if isSkip(err) {
+		p.logger.Printf("Skipped: %s: %v", path, err)
+
+		return newReport(opts, started, 0, nil, []skippedFile{{Path: path, Reason: err.Error()}}, &collectorError{}), nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("fixing file: %w", err)
+	}
+
+	if _, err := p.stdout.Write(result.Output); err != nil {
+		return nil, fmt.Errorf("write output: %w", err)
+	}
+
+	return newReport(opts, started, 1, []fileResult{result}, nil, &collectorError{}), nil
+}
+
+// walkDir returns the Go files below root in lexical order. Directories are read by up
+// to opts.WalkWorkers goroutines at once; when all are busy a directory is read by the
+// goroutine that found it, which keeps the walk bounded without risking a deadlock.
+func (p *Processor) walkDir(ctx context.Context, root string) ([]string, error) {
+	ctx, span := p.tracer.start(ctx, "walk", "root", root)
+	defer span.finish()
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	var (
+		mu    sync.Mutex
+		files = []string{}
+		wg    sync.WaitGroup
+	)
+
+	sem := make(chan struct{}, max(p.opts.WalkWorkers, 1))
+
+	var walk func(dir string)
+
+	walk = func(dir string) {
+		if isCancelled(ctx) {
+			return
+		}
+
+		entries, err := p.fsys.ReadDir(dir)
+		if err != nil {
+			cancel(err)
+
+			return
+		}
+
+		for _, entry := range entries {
+			pathStr := filepath.Join(dir, entry.Name())
+
+			if p.excluded(relPath(root, pathStr)) {
+				continue
 			}
 
-			if isCancelled(ctx) {
-				return fmt.Errorf("context error: %w", ctx.Err())
+			if entry.IsDir() {
+				if p.skipVendor(entry.Name()) || p.skipHidden(entry.Name()) || p.skipTestdata(entry.Name()) {
+					continue
+				}
+
+				wg.Add(1)
+
+				select {
+				case sem <- struct{}{}:
+					go func() {
+						defer wg.Done()
+						defer func() { <-sem }()
+
+						walk(pathStr)
+					}()
+				default:
+					walk(pathStr)
+					wg.Done()
+				}
+
+				continue
 			}
 
+			if isEditorArtifact(entry.Name()) || !strings.HasSuffix(pathStr, ".go") {
+				continue
+			}
+
+			selected, err := p.selectFile(relPath(root, pathStr), pathStr, entry)
+			if err != nil {
+				cancel(err)
+
+				return
+			}
+
+			if !selected {
+				continue
+			}
+
+			mu.Lock()
 			files = append(files, pathStr)
+			mu.Unlock()
+		}
+	}
+
+	walk(root)
+	wg.Wait()
 
-			return nil
-		}); err != nil {
-			return fmt.Errorf("walking directory: %w", err)
+	if err := context.Cause(ctx); err != nil {
+		if errors.Is(err, context.Canceled) {
+			return nil, fmt.Errorf("context error: %w", err)
 		}
 
-		pool := newWorkerPool(ctx, numWorkers)
+		return nil, fmt.Errorf("walking directory: %w", err)
+	}
 
-		pool.Start()
+	slices.Sort(files)
 
-		for _, file := range files {
-			if isCancelled(ctx) {
-				break
-			}
+	return files, nil
+}
+
+// selectFile reports whether the Go file path, named rel relative to the root it was
+// found from, passes -exclude, -skip-tests, -only-tests and the metadata filters.
+// Files found by walking a directory, resolved from a package pattern and named on the
+// command line all go through it.
+func (p *Processor) selectFile(rel, path string, entry fs.DirEntry) (bool, error) {
+	if p.excluded(rel) || p.skipTestFile(path) {
+		return false, nil
+	}
+
+	skip, err := p.skipFile(entry)
+	if err != nil {
+		return false, err
+	}
+
+	return !skip, nil
+}
+
+// skipFile reports whether a file found by the walk is filtered out by its metadata.
+func (p *Processor) skipFile(dir fs.DirEntry) (bool, error) {
+	opts := p.opts
+
+	if opts.NewerThan.IsZero() && opts.MinSize <= 0 && opts.MaxSize <= 0 {
+		return false, nil
+	}
+
+	info, err := dir.Info()
+	if err != nil {
+		return false, fmt.Errorf("file info: %w", err)
+	}
+
+	if !opts.NewerThan.IsZero() && !info.ModTime().After(opts.NewerThan) {
+		return true, nil
+	}
+
+	if opts.MinSize > 0 && info.Size() < opts.MinSize {
+		return true, nil
+	}
+
+	if opts.MaxSize > 0 && info.Size() > opts.MaxSize {
+		return true, nil
+	}
+
+	return false, nil
+}
 
-			pool.AddJob(file)
+// vendorDirs are dependency trees and version control metadata, which the walk skips
+// unless opts.IncludeVendor is set.
+var vendorDirs = map[string]bool{
+	"vendor":       true,
+	"node_modules": true,
+	".git":         true,
+	".hg":          true,
+	".svn":         true,
+	".bzr":         true,
+}
+
+func (p *Processor) skipVendor(name string) bool {
+	return vendorDirs[name] && !p.opts.IncludeVendor
+}
+
+// skipTestFile reports whether filename is excluded by opts.SkipTests or opts.OnlyTests.
+func (p *Processor) skipTestFile(filename string) bool {
+	isTest := strings.HasSuffix(filename, "_test.go")
+
+	return p.opts.SkipTests && isTest || p.opts.OnlyTests && !isTest
+}
+
+func (p *Processor) skipTestdata(name string) bool {
+	return name == "testdata" && !p.opts.IncludeTestdata
+}
+
+// skipHidden reports whether the hidden directory name is left out of the walk.
+func (p *Processor) skipHidden(name string) bool {
+	if !strings.HasPrefix(name, ".") || p.opts.IncludeHidden {
+		return false
+	}
+
+	for _, pattern := range p.opts.IncludeHiddenPatterns {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return false
 		}
+	}
+
+	return true
+}
 
-		pool.Wait()
+func (p *Processor) processFiles(ctx context.Context, files []string, numWorkers int, started time.Time) (*report, error) {
+	ctx, err := p.withTypedSkips(ctx, files)
+	if err != nil {
+		return nil, fmt.Errorf("skip types: %w", err)
+	}
 
-		log.Printf("Successfully processed %d files", pool.GetProcessedCount())
+	pool := newWorkerPool(ctx, numWorkers, p)
 
-		if pool.collectorError.HasErrors() {
-			return fmt.Errorf("errors occurred during processing: %w", pool.collectorError)
+	if p.opts.Gofmt {
+		pool.output = newOutputSequencer(p.stdout, files)
+	}
+
+	pool.Start()
+
+	for _, file := range files {
+		if isCancelled(ctx) {
+			break
 		}
 
-		return nil
+		pool.AddJob(file)
 	}
 
-	if !strings.HasSuffix(path, ".go") {
-		return fmt.Errorf("not a .go file: %s", path)
+	pool.Wait()
+
+	if err := pool.output.close(); err != nil {
+		pool.collectorError.Add(fmt.Errorf("write output: %w", err))
 	}
 
-	if err := fixFile(ctx, path); err != nil {
-		return fmt.Errorf("fixing file: %w", err)
+	p.logger.Printf("Successfully processed %d files", pool.GetProcessedCount())
+
+	rep := newReport(p.opts, started, pool.GetProcessedCount(), pool.Results(), pool.Skipped(), pool.collectorError)
+
+	if pool.collectorError.HasErrors() {
+		return rep, fmt.Errorf("errors occurred during processing: %w", pool.collectorError)
 	}
 
-	return nil
+	return rep, nil
+}
+
+var errFileTimeout = errors.New("file processing timed out")
+
+// isSkip reports whether err means that a file was deliberately left unprocessed.
+func isSkip(err error) bool {
+	return errors.Is(err, errFileTimeout) || errors.Is(err, errHeaderSkipped) || errors.Is(err, quotedconv.ErrFileIgnored) || errors.Is(err, errNestingTooDeep)
+}
+
+// skippedFile records a file that was deliberately left unprocessed.
+type skippedFile struct {
+	Path   string
+	Reason string
+}
+
+// fixFileWithTimeout runs FixFile under opts.FileTimeout. The worker returns as soon as
+// the deadline passes, even if parsing a pathological file is still in progress; the
+// abandoned call observes the cancelled context and never writes.
+func (p *Processor) fixFileWithTimeout(ctx context.Context, filename string) (fileResult, error) {
+	opts := p.opts
+
+	if opts.FileTimeout <= 0 {
+		return p.FixFile(ctx, filename)
+	}
+
+	fileCtx, cancel := context.WithTimeout(ctx, opts.FileTimeout)
+	defer cancel()
+
+	type outcome struct {
+		result fileResult
+		err    error
+	}
+
+	done := make(chan outcome, 1)
+
+	go func() {
+		result, err := p.FixFile(fileCtx, filename)
+		done <- outcome{result: result, err: err}
+	}()
+
+	select {
+	case o := <-done:
+		if o.err != nil && isCancelled(fileCtx) && !isCancelled(ctx) {
+			return o.result, fmt.Errorf("%w after %s", errFileTimeout, opts.FileTimeout)
+		}
+
+		return o.result, o.err
+	case <-fileCtx.Done():
+		if isCancelled(ctx) {
+			return fileResult{Path: filename, Changes: nil, Diff: "", BeforeSHA256: "", AfterSHA256: "", Insertions: 0, Deletions: 0, Output: nil, Suppressed: nil, Edits: nil}, fmt.Errorf("context error: %w", ctx.Err())
+		}
+
+		return fileResult{Path: filename, Changes: nil, Diff: "", BeforeSHA256: "", AfterSHA256: "", Insertions: 0, Deletions: 0, Output: nil, Suppressed: nil, Edits: nil}, fmt.Errorf("%w after %s", errFileTimeout, opts.FileTimeout)
+	}
 }
 
-func fixFile(ctx context.Context, filename string) error {
+// fileResult holds the outcome of processing a single file.
+type fileResult struct {
+	Path string
+	// Package is the import path of the package of a changed file where it is known,
+	// and its package name otherwise.
+	Package string
+	Changes []quotedconv.Change
+	Diff    string
+	// BeforeSHA256 and AfterSHA256 are the content hashes of a rewritten file.
+	BeforeSHA256 string
+	AfterSHA256  string
+	// Insertions and Deletions count the changed lines of a rewritten file.
+	Insertions int
+	Deletions  int
+	// Output is what gofmt mode prints to standard output for the file.
+	Output []byte
+	// Suppressed lists the convertible literals left alone by heuristics or directives,
+	// collected in strict mode.
+	Suppressed []quotedconv.Suppression
+	// Edits are the changes as Language Server Protocol text edits, computed for the LSP
+	// report format.
+	Edits []quotedconv.LSPTextEdit
+}
+
+func (p *Processor) FixFile(ctx context.Context, filename string) (fileResult, error) {
+	opts := p.opts
+	result := fileResult{Path: filename, Changes: nil, Diff: "", BeforeSHA256: "", AfterSHA256: "", Insertions: 0, Deletions: 0, Output: nil, Suppressed: nil, Edits: nil}
+
+	ctx, span := p.tracer.start(ctx, "file", "path", filename)
+	defer span.finish()
+
 	if isCancelled(ctx) {
-		return fmt.Errorf("context error: %w", ctx.Err())
+		return result, fmt.Errorf("context error: %w", ctx.Err())
 	}
 
-	src, err := os.ReadFile(filename)
+	src, release, err := readSource(p.fsys, filename, opts.MmapThreshold)
 	if err != nil {
-		return fmt.Errorf("read file: %w", err)
+		return result, err
+	}
+	defer release()
+
+	if re, ok := p.matchSkipHeader(src); ok {
+		return result, fmt.Errorf("%w %q", errHeaderSkipped, re)
+	}
+
+	if !p.packageSelected(src) {
+		result.Output = p.gofmtOutput(filename, src, nil, nil)
+
+		return result, nil
 	}
 
-	file, fset, err := parseGoFile(filename, src)
+	if opts.MaxNesting > 0 {
+		if depth := nestingDepth(src); depth > opts.MaxNesting {
+			return result, fmt.Errorf("%w: depth %d exceeds %d", errNestingTooDeep, depth, opts.MaxNesting)
+		}
+	}
+
+	converted, err := p.cachedConvert(ctx, filename, src)
 	if err != nil {
-		return err
+		return result, err
 	}
 
-	changed := processAST(ctx, file)
-	if !changed {
-		return nil
+	changes, formatted := converted.Changes, converted.Source
+	result.Suppressed = converted.Suppressed
+
+	if len(changes) == 0 {
+		result.Output = p.gofmtOutput(filename, src, nil, nil)
+
+		return result, nil
 	}
 
-	if err := writeFormattedFile(filename, fset, file); err != nil {
-		return fmt.Errorf("write file: %w", err)
+	result.Package = p.packageOf(filename, src)
+
+	write := needsWrite(src, formatted)
+	if write {
+		// Hash and diff before unmapping; src is not accessible afterwards.
+		result.BeforeSHA256 = sha256Hex(src)
+		result.Insertions, result.Deletions = diff.Stat(src, formatted)
 	}
 
-	return nil
+	var snippets string
+	if write && opts.Verbose {
+		snippets = renderChangeSnippets(src, changes)
+	}
+
+	if write && opts.Format == FormatLSP {
+		result.Edits = quotedconv.LSPEdits(src, changes)
+	}
+
+	if write && opts.ShowContent && (opts.Format == FormatMarkdown || opts.GitHubSummaryPath != "") {
+		result.Diff = annotatedDiff(filename, src, formatted, changes)
+	}
+
+	if write {
+		result.Output = p.gofmtOutput(filename, src, formatted, changes)
+	} else {
+		result.Output = p.gofmtOutput(filename, src, nil, nil)
+	}
+
+	// A mapped file must be unmapped before it is rewritten in place.
+	release()
+
+	if !write {
+		return result, nil
+	}
+
+	if opts.writes() {
+		// A file given up on after -file-timeout is reported as skipped and must stay so.
+		if isCancelled(ctx) {
+			return result, fmt.Errorf("context error: %w", ctx.Err())
+		}
+
+		if err := p.writeFile(ctx, filename, formatted); err != nil {
+			return result, fmt.Errorf("write file: %w", err)
+		}
+
+		result.AfterSHA256 = sha256Hex(formatted)
+
+		// The rewritten file is a fixpoint; saving it again needs no work.
+		if _, overrides, err := p.dirs.converter(filename); err == nil {
+			p.cache.put(decisionKey(formatted, overrides), quotedconv.Result{Changes: nil, Source: nil, Suppressed: nil})
+		}
+	} else if opts.DryRun || opts.Check {
+		p.logger.Printf("Would fix: %s", filename)
+	}
+
+	result.Changes = changes
+
+	for _, c := range changes {
+		p.logger.Printf("  %s", c)
+	}
+
+	if snippets != "" {
+		p.logger.Printf("%s", strings.TrimSuffix(snippets, "\n"))
+	}
+
+	if opts.ShowLiterals {
+		p.logLiterals(changes)
+	}
+
+	return result, nil
 }
 
-func parseGoFile(filename string, src []byte) (*ast.File, *token.FileSet, error) {
-	fset := token.NewFileSet()
+// cachedConvert converts src with the converter for the directory of filename, backed
+// by the decision cache if the processor has one. Results only depend on the content
+// and the options, so they are keyed by content hash and per-directory overrides.
+func (p *Processor) cachedConvert(ctx context.Context, filename string, src []byte) (quotedconv.Result, error) {
+	converter, overrides, err := p.dirs.converter(filename)
+	if err != nil {
+		return quotedconv.Result{Changes: nil, Source: nil, Suppressed: nil}, err
+	}
+
+	if converter == nil {
+		converter = p.converter
+	}
+
+	// Skipping typed literals depends on other files, which the cache cannot see, and
+	// suppressions are only recorded while converting.
+	if p.cache == nil || len(p.skipTypes) > 0 || p.opts.Strict {
+		return converter.Convert(ctx, filename, src)
+	}
+
+	key := decisionKey(src, overrides)
 
-	file, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+	if result, ok := p.cache.get(key, filename); ok {
+		return result, nil
+	}
+
+	result, err := converter.Convert(ctx, filename, src)
 	if err != nil {
-		return nil, nil, fmt.Errorf("parse file: %w", err)
+		return result, err
 	}
 
-	return file, fset, nil
+	p.cache.put(key, result)
+
+	return result, nil
 }
 
-func processAST(ctx context.Context, file *ast.File) bool {
-	changed := false
+// decisionKey returns the decision cache key of src converted with the per-directory
+// overrides identified by overrides.
+func decisionKey(src []byte, overrides string) string {
+	if overrides == "" {
+		return sha256Hex(src)
+	}
 
-	tagPositions := make(map[token.Pos]bool)
+	return sha256Hex(src) + "\x00" + overrides
+}
 
-	ast.Inspect(file, func(n ast.Node) bool {
-		if field, ok := n.(*ast.Field); ok && field.Tag != nil {
-			tagPositions[field.Tag.Pos()] = true
-		}
+// snippetContext is the number of source lines shown around a change in verbose mode.
+const snippetContext = 1
 
-		return true
-	})
+func renderChangeSnippets(src []byte, changes []quotedconv.Change) string {
+	var b strings.Builder
 
-	ast.Inspect(file, func(n ast.Node) bool {
-		if isCancelled(ctx) {
-			return false
-		}
+	for _, c := range changes {
+		fmt.Fprintf(&b, "%s: %s: %s\n", c.Pos, c.Rule, c.Detail)
+		b.WriteString(redactText(RenderSnippet(src, c.Pos.Offset, len(c.Before), snippetContext), []quotedconv.Change{c}))
+	}
+
+	return b.String()
+}
 
-		lit, ok := n.(*ast.BasicLit)
-		if !ok || lit.Kind != token.STRING {
-			return true
+// logLiterals logs the before and after text of every change for -show-literals, to the
+// logger rather than standard output, which may carry a report or a file list.
+func (p *Processor) logLiterals(changes []quotedconv.Change) {
+	for _, c := range changes {
+		if !p.opts.ShowContent {
+			p.logger.Printf("  %s: %d bytes -> %d bytes", c.Pos, len(c.Before), len(c.After))
+
+			continue
 		}
 
-		if tagPositions[lit.Pos()] {
-			return true
+		c = redacted(c)
+		p.logger.Printf("  %s: %s -> %s", c.Pos, displayLiteral(c.Before), displayLiteral(c.After))
+	}
+}
+
+// displayLiteral makes a literal safe to print on a terminal: it is truncated and
+// non-printable runes are shown as escape sequences.
+func displayLiteral(value string) string {
+	const maxRunes = 60
+
+	var b strings.Builder
+
+	n := 0
+	for _, r := range value {
+		if n == maxRunes {
+			b.WriteString("...")
+
+			break
 		}
 
-		if shouldConvertLiteral(lit.Value) {
-			content := lit.Value[1 : len(lit.Value)-1]
-			lit.Value = strconv.Quote(content)
-			changed = true
+		if r != utf8.RuneError && unicode.IsPrint(r) {
+			b.WriteRune(r)
+		} else {
+			quoted := strconv.QuoteRuneToASCII(r)
+			b.WriteString(quoted[1 : len(quoted)-1])
 		}
 
-		return true
-	})
+		n++
+	}
 
-	return changed
+	return b.String()
 }
 
-func writeFormattedFile(filename string, fset *token.FileSet, file *ast.File) error {
-	var buf strings.Builder
-	if err := printer.Fprint(&buf, fset, file); err != nil {
-		return fmt.Errorf("print file: %w", err)
+// needsWrite reports whether formatted differs from src in more than formatting. A file
+// is never rewritten when the result is identical to the original, nor when gofmt alone
+// would turn the original into the result.
+func needsWrite(src, formatted []byte) bool {
+	if bytes.Equal(src, formatted) {
+		return false
 	}
 
-	formatted, err := format.Source([]byte(buf.String()))
+	gofmted, err := format.Source(src)
 	if err != nil {
-		return fmt.Errorf("format source: %w", err)
+		return true
 	}
 
-	if err := os.WriteFile(filename, formatted, 0644); err != nil {
-		return fmt.Errorf("write file: %w", err)
+	return !bytes.Equal(gofmted, formatted)
+}
+
+func (p *Processor) writeFile(ctx context.Context, filename string, formatted []byte) (err error) {
+	_, span := p.tracer.start(ctx, "write")
+	defer func() {
+		span.fail(err)
+		span.finish()
+	}()
+
+	if p.writeSem != nil {
+		select {
+		case p.writeSem <- struct{}{}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		defer func() { <-p.writeSem }()
 	}
 
-	log.Printf("Fixed: %s", filename)
+	perm := p.opts.FileMode
+	if perm == 0 {
+		info, err := p.fsys.Stat(filename)
+		if err != nil {
+			return fmt.Errorf("stat file: %w", err)
+		}
 
-	return nil
-}
+		perm = info.Mode().Perm()
+	}
 
-func shouldConvertLiteral(value string) bool {
-	if !strings.HasPrefix(value, "`") || !strings.HasSuffix(value, "`") {
-		return false
+	// Checked last, right before writing: once a file has timed out it is reported as
+	// skipped, so it must be left untouched even if the timeout fired while waiting.
+	if err := ctx.Err(); err != nil {
+		return err
 	}
 
-	content := value[1 : len(value)-1]
-	if strings.Contains(content, "\"") {
-		return false
+	if _, ok := p.fsys.(osFS); ok && p.opts.Durable {
+		if err := writeFileDurable(filename, formatted, perm); err != nil {
+			return fmt.Errorf("write file: %w", err)
+		}
+	} else if err := p.fsys.WriteFile(filename, formatted, perm); err != nil {
+		return fmt.Errorf("write file: %w", err)
 	}
 
-	return !strings.ContainsAny(content, "\n`\\")
+	p.logger.Printf("Fixed: %s", filename)
+
+	return nil
 }
 
 func isCancelled(ctx context.Context) bool {
@@ -270,11 +1141,17 @@ type workerPool struct {
 	jobChan        chan string
 	numWorkers     int
 	ctx            context.Context
+	processor      *Processor
 	collectorError *collectorError
 	processedFiles int32
+	mu             sync.Mutex
+	results        []fileResult
+	skipped        []skippedFile
+	// output sequences the gofmt mode output of the files; nil outside gofmt mode.
+	output *outputSequencer
 }
 
-func newWorkerPool(ctx context.Context, numWorkers int) *workerPool {
+func newWorkerPool(ctx context.Context, numWorkers int, processor *Processor) *workerPool {
 	if numWorkers <= 0 {
 		numWorkers = runtime.NumCPU()
 	}
@@ -286,11 +1163,16 @@ func newWorkerPool(ctx context.Context, numWorkers int) *workerPool {
 		jobChan:    make(chan string, numWorkers*chanSize),
 		numWorkers: numWorkers,
 		ctx:        ctx,
+		processor:  processor,
 		collectorError: &collectorError{
 			mu:     sync.Mutex{},
 			errors: []error{},
 		},
 		processedFiles: 0,
+		mu:             sync.Mutex{},
+		results:        []fileResult{},
+		skipped:        []skippedFile{},
+		output:         nil,
 	}
 }
 
@@ -306,11 +1188,17 @@ func (wp *workerPool) Start() {
 					return
 				}
 
-				err := fixFile(wp.ctx, filePath)
-				if err != nil && !errors.Is(err, context.Canceled) {
+				result, err := wp.processor.fixFileWithTimeout(wp.ctx, filePath)
+				wp.output.done(filePath, result.Output)
+
+				if isSkip(err) {
+					wp.processor.logger.Printf("Skipped: %s: %v", filePath, err)
+					wp.addSkipped(skippedFile{Path: filePath, Reason: err.Error()})
+				} else if err != nil && !errors.Is(err, context.Canceled) {
 					wp.collectorError.Add(fmt.Errorf("error processing file %s: %w", filePath, err))
 				} else if err == nil {
 					atomic.AddInt32(&wp.processedFiles, 1)
+					wp.addResult(result)
 				}
 			}
 		}()
@@ -329,3 +1217,45 @@ func (wp *workerPool) Wait() {
 func (wp *workerPool) GetProcessedCount() int {
 	return int(atomic.LoadInt32(&wp.processedFiles))
 }
+
+func (wp *workerPool) addResult(result fileResult) {
+	if len(result.Changes) == 0 {
+		return
+	}
+
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+
+	wp.results = append(wp.results, result)
+}
+
+func (wp *workerPool) Results() []fileResult {
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+
+	results := slices.Clone(wp.results)
+	slices.SortFunc(results, func(a, b fileResult) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+
+	return results
+}
+
+func (wp *workerPool) addSkipped(skipped skippedFile) {
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+
+	wp.skipped = append(wp.skipped, skipped)
+}
+
+func (wp *workerPool) Skipped() []skippedFile {
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+
+	skipped := slices.Clone(wp.skipped)
+	slices.SortFunc(skipped, func(a, b skippedFile) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+
+	return skipped
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/fs"
+	"log"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestFixFileLogsToLogger(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"a.go": "package m\n\nvar A = `a`\n",
+	})
+
+	opts := defaultOptions()
+	opts.DryRun = true
+	opts.Verbose = true
+	opts.ShowLiterals = true
+	opts.ShowContent = true
+
+	var logs, stdout bytes.Buffer
+
+	p, err := NewProcessor(opts, log.New(&logs, "", 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p.stdout = &stdout
+
+	if _, err := p.FixFile(context.Background(), filepath.Join(dir, "a.go")); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		"a.go:3:9: raw-to-interpreted: 0 escapes added",
+		"var A = `a`",
+		"a.go:3:9: `a` -> \"a\"",
+	} {
+		if !strings.Contains(logs.String(), want) {
+			t.Errorf("log does not contain %q:\n%s", want, logs.String())
+		}
+	}
+
+	if stdout.Len() > 0 {
+		t.Errorf("FixFile wrote to standard output: %q", stdout.String())
+	}
+}
+
+// slowFS is a memFS whose Stat calls, made just before a file is written, block until
+// release is closed.
+type slowFS struct {
+	*memFS
+	release chan struct{}
+}
+
+func (s *slowFS) Stat(name string) (fs.FileInfo, error) {
+	<-s.release
+
+	return s.memFS.Stat(name)
+}
+
+func TestFixFileTimeoutLeavesFileUntouched(t *testing.T) {
+	const src = "package m\n\nvar A = `a`\n"
+
+	fsys := &slowFS{
+		memFS:   &memFS{files: fstest.MapFS{"a.go": {Data: []byte(src), Mode: 0o644}}},
+		release: make(chan struct{}),
+	}
+
+	opts := defaultOptions()
+	opts.FS = fsys
+	opts.FileTimeout = time.Millisecond
+
+	p, err := NewProcessor(opts, log.New(&bytes.Buffer{}, "", 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := p.fixFileWithTimeout(context.Background(), "a.go"); !errors.Is(err, errFileTimeout) {
+		t.Fatalf("fixFileWithTimeout error = %v, want %v", err, errFileTimeout)
+	}
+
+	// Let the abandoned conversion finish.
+	close(fsys.release)
+	time.Sleep(100 * time.Millisecond)
+
+	if got := string(fsys.files["a.go"].Data); got != src {
+		t.Errorf("a.go was rewritten after timing out:\n%s", got)
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package main
+
+import "errors"
+
+func mmapFile(string, int64) ([]byte, func(), error) {
+	return nil, nil, errors.New("mmap: unsupported platform")
+}
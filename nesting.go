@@ -0,0 +1,41 @@
+package main
+
+import (
+	"errors"
+	"go/scanner"
+	"go/token"
+)
+
+// defaultMaxNesting is the default bracket nesting limit. Hand-written code stays far
+// below it; files above it are machine-generated and not worth the risk.
+const defaultMaxNesting = 1000
+
+// errNestingTooDeep reports a file nested deeper than opts.MaxNesting.
+var errNestingTooDeep = errors.New("nesting too deep")
+
+// nestingDepth returns the maximum bracket nesting depth of src. It only scans tokens,
+// iteratively, so it is safe on inputs that would exhaust the stack of the recursive
+// parser, printer and AST walks.
+func nestingDepth(src []byte) int {
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(src))
+
+	var s scanner.Scanner
+	s.Init(file, src, nil, 0)
+
+	depth, deepest := 0, 0
+
+	for {
+		_, tok, _ := s.Scan()
+
+		switch tok {
+		case token.EOF:
+			return deepest
+		case token.LPAREN, token.LBRACK, token.LBRACE:
+			depth++
+			deepest = max(deepest, depth)
+		case token.RPAREN, token.RBRACK, token.RBRACE:
+			depth--
+		}
+	}
+}
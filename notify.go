@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const notifyTimeout = 10 * time.Second
+
+// notify POSTs the run summary to url. By default the body is the JSON report; with
+// slack set it is a Slack-compatible incoming-webhook payload instead, which also
+// carries the schema version; Slack ignores fields it does not know.
+func notify(ctx context.Context, url string, rep *report, slack bool) error {
+	var payload any = rep
+	if slack {
+		payload = map[string]any{
+			"schemaVersion": rep.SchemaVersion,
+			"text":          fmt.Sprintf("quotedconv: %s (run %s on %s)", summaryLine(rep), rep.Run.ID, rep.Run.Hostname),
+		}
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("encode payload: %w", err)
+	}
+
+	// The run context may already be cancelled; the notification should still go out.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), notifyTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("post: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+
+	return nil
+}
@@ -0,0 +1,413 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/otakakot/quotedconv/quotedconv"
+)
+
+// Format selects how the run report is written.
+type Format string
+
+const (
+	// FormatText reports changes as log lines only.
+	FormatText Format = "text"
+	// FormatJSON writes a JSON report to standard output.
+	FormatJSON Format = "json"
+	// FormatMarkdown writes a Markdown summary suitable for a PR description.
+	FormatMarkdown Format = "markdown"
+	// FormatQuickfix writes one file:line:col: message line per change, as understood
+	// by the default Vim errorformat and Emacs compilation mode.
+	FormatQuickfix Format = "quickfix"
+	// FormatLSP writes a Language Server Protocol WorkspaceEdit with the text edits of
+	// every change.
+	FormatLSP Format = "lsp"
+)
+
+func (f *Format) String() string {
+	return string(*f)
+}
+
+func (f *Format) Set(value string) error {
+	switch Format(value) {
+	case FormatText, FormatJSON, FormatMarkdown, FormatQuickfix, FormatLSP:
+		*f = Format(value)
+
+		return nil
+	default:
+		return fmt.Errorf("unknown format %q", value)
+	}
+}
+
+// stringList is a repeatable string flag.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+
+	return nil
+}
+
+// commaList is a repeatable string flag whose values may also be comma-separated.
+type commaList []string
+
+func (l *commaList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *commaList) Set(value string) error {
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			*l = append(*l, item)
+		}
+	}
+
+	return nil
+}
+
+// newerThan is a time flag accepting an RFC 3339 timestamp, a date, or a duration
+// that is subtracted from the current time.
+type newerThan time.Time
+
+func (n *newerThan) String() string {
+	if t := time.Time(*n); !t.IsZero() {
+		return t.Format(time.RFC3339)
+	}
+
+	return ""
+}
+
+func (n *newerThan) Set(value string) error {
+	if d, err := time.ParseDuration(value); err == nil {
+		*n = newerThan(time.Now().Add(-d))
+
+		return nil
+	}
+
+	for _, layout := range []string{time.RFC3339, time.DateTime, time.DateOnly} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			*n = newerThan(t)
+
+			return nil
+		}
+	}
+
+	return fmt.Errorf("invalid time or duration %q", value)
+}
+
+// fileMode is an octal permission flag such as 0640. The zero value means that the
+// permissions of the original file are preserved.
+type fileMode os.FileMode
+
+func (m *fileMode) String() string {
+	if m == nil || *m == 0 {
+		return "preserve"
+	}
+
+	return fmt.Sprintf("%#o", uint32(*m))
+}
+
+func (m *fileMode) Set(value string) error {
+	if value == "preserve" {
+		*m = 0
+
+		return nil
+	}
+
+	n, err := strconv.ParseUint(value, 8, 32)
+	if err != nil || n == 0 || n > 0o777 {
+		return fmt.Errorf("invalid file mode %q", value)
+	}
+
+	*m = fileMode(n)
+
+	return nil
+}
+
+// byteSize is a size flag accepting a plain number of bytes or a number with a
+// K, M or G suffix (optionally followed by B or iB; all are powers of 1024).
+type byteSize int64
+
+func (b *byteSize) String() string {
+	return strconv.FormatInt(int64(*b), 10)
+}
+
+func (b *byteSize) Set(value string) error {
+	number := strings.TrimSuffix(strings.TrimSuffix(strings.ToUpper(strings.TrimSpace(value)), "B"), "I")
+	multiplier := int64(1)
+
+	for suffix, m := range map[string]int64{"K": 1 << 10, "M": 1 << 20, "G": 1 << 30} {
+		if strings.HasSuffix(number, suffix) {
+			number, multiplier = strings.TrimSuffix(number, suffix), m
+
+			break
+		}
+	}
+
+	n, err := strconv.ParseInt(number, 10, 64)
+	if err != nil || n < 0 {
+		return fmt.Errorf("invalid size %q", value)
+	}
+
+	*b = byteSize(n * multiplier)
+
+	return nil
+}
+
+// Options controls which literals are converted and how.
+type Options struct {
+	quotedconv.Options
+	// ShowLiterals prints every converted literal with its before and after text.
+	ShowLiterals bool
+	// ShowContent includes literal contents in diagnostics, reports and diffs. Without
+	// it only positions and lengths are reported, so output can be shared safely.
+	ShowContent bool
+	// Stat prints per-file insertions and deletions like git diff --stat.
+	Stat bool
+	// Census counts the convertible literals by the type they are used as.
+	Census        bool
+	Format        Format
+	FormatVersion int
+	// FileTimeout bounds the time spent on a single file; zero means no limit.
+	FileTimeout time.Duration
+	// MmapThreshold is the file size in bytes from which files are memory-mapped
+	// instead of read into memory; zero disables memory mapping.
+	MmapThreshold int64
+	// NotifyURL receives the run summary when the run finishes.
+	NotifyURL string
+	// NotifySlack sends a Slack-compatible payload to NotifyURL instead of the JSON report.
+	NotifySlack bool
+	// AuditLog is the file an audit record of every run is appended to.
+	AuditLog string
+	// GitHubSummaryPath is the GitHub Actions step summary file the Markdown summary is
+	// appended to; empty disables it.
+	GitHubSummaryPath string
+	// Watch keeps the tool running and converts files as they change.
+	Watch bool
+	// WatchInterval is how often watch mode polls for changes.
+	WatchInterval time.Duration
+	// WatchDebounce is the quiet period after the last change before a batch runs.
+	WatchDebounce time.Duration
+	// NewerThan restricts directory walks to files modified after this time.
+	NewerThan time.Time
+	// Workers is the number of files converted at once; zero means the number of CPUs.
+	Workers int
+	// WalkWorkers bounds the number of directories read concurrently.
+	WalkWorkers int
+	// MaxWriteConcurrency bounds the number of files written at once, independently of
+	// the number of workers; zero means no limit.
+	MaxWriteConcurrency int
+	// Durable writes files through a synced temporary file and syncs the directory
+	// after renaming it over the original.
+	Durable bool
+	// FileMode sets the permissions of written files; zero preserves the permissions of
+	// the original file.
+	FileMode os.FileMode
+	// MinSize and MaxSize restrict directory walks to files within a size range in
+	// bytes; zero disables the bound.
+	MinSize int64
+	MaxSize int64
+	// Packages restricts processing to files whose package clause matches one of these
+	// names or path.Match globs.
+	Packages []string
+	// SkipTests leaves _test.go files alone; OnlyTests processes nothing else. They are
+	// mutually exclusive.
+	SkipTests bool
+	OnlyTests bool
+	// SkipTypes names string types, as package.Type, whose literals are never converted,
+	// such as wrapper types marking SQL or regular expressions. Setting it loads type
+	// information for every processed package.
+	SkipTypes []string
+	// IncludeVendor processes vendor, node_modules and version control directories,
+	// which are skipped by default.
+	IncludeVendor bool
+	// IncludeTestdata processes testdata directories, which hold fixtures and golden
+	// files and are skipped by default.
+	IncludeTestdata bool
+	// IncludeHidden processes hidden directories, which are skipped by default.
+	IncludeHidden bool
+	// Excludes are globs of files and directories, relative to the target they are found
+	// under, that are skipped; "**" matches any number of directories.
+	Excludes []string
+	// IncludeHiddenPatterns lists globs of hidden directory names that are processed
+	// even though IncludeHidden is false.
+	IncludeHiddenPatterns []string
+	// SkipHeaders are regular expressions matched against the header of every file,
+	// everything before the package clause; files with a matching header are skipped.
+	SkipHeaders []string
+	// MaxNesting skips files whose bracket nesting exceeds it, so pathological
+	// machine-generated files cannot exhaust the stack; zero disables the limit.
+	MaxNesting int
+	// FS is the file system source and configuration files are read from and written
+	// to; nil means the operating system's.
+	FS FS `json:"-"`
+	// Config is the configuration file the options were read from. Its [dir] tables, and
+	// the configuration files in directories below it, override conversion options for
+	// the files they cover.
+	Config string
+	// Pinned names the flags given on the command line or in the environment, and those
+	// of the options set by shorthand flags among them, which configuration files never
+	// override.
+	Pinned []string
+	// Overlay is a JSON file mapping file paths to contents that replace them, such as
+	// the unsaved buffers of an editor. Overlaid files are never written.
+	Overlay string
+	// DryRun reports changes without writing any file.
+	DryRun bool
+	// Check is DryRun for CI gating: the run fails if any file would change.
+	Check bool
+	// Strict records the convertible literals left alone by heuristics or directives,
+	// and the run fails if there are any.
+	Strict bool
+	// Gofmt switches to the output semantics of gofmt: files are only rewritten with
+	// Write, and unless List, Diff or Write is set the converted source is printed.
+	Gofmt bool
+	// List prints the names of files that would change.
+	List bool
+	// Print0 terminates the names printed by List with NUL instead of newline.
+	Print0 bool
+	// Diff prints a diff of every file that would change.
+	Diff bool
+	// Write rewrites files in gofmt mode.
+	Write bool
+	// Txtar reads a txtar archive instead of Go files and writes it with its Go files
+	// converted.
+	Txtar bool
+}
+
+// walkWorkers is the default directory walk concurrency. Reading directories is I/O
+// bound, so it exceeds the CPU count to hide latency on network filesystems.
+const walkWorkers = 16
+
+func defaultOptions() Options {
+	return Options{
+		Options:               quotedconv.DefaultOptions(),
+		ShowLiterals:          false,
+		ShowContent:           false,
+		Stat:                  false,
+		Census:                false,
+		Format:                FormatText,
+		FormatVersion:         reportSchemaVersion,
+		FileTimeout:           0,
+		MmapThreshold:         0,
+		NotifyURL:             "",
+		NotifySlack:           false,
+		AuditLog:              "",
+		GitHubSummaryPath:     "",
+		Watch:                 false,
+		WatchInterval:         500 * time.Millisecond,
+		WatchDebounce:         time.Second,
+		NewerThan:             time.Time{},
+		Workers:               0,
+		WalkWorkers:           walkWorkers,
+		MaxWriteConcurrency:   0,
+		Durable:               false,
+		FileMode:              0,
+		MinSize:               0,
+		MaxSize:               0,
+		Packages:              nil,
+		SkipTests:             false,
+		OnlyTests:             false,
+		SkipTypes:             nil,
+		IncludeVendor:         false,
+		IncludeTestdata:       false,
+		IncludeHidden:         false,
+		Excludes:              nil,
+		IncludeHiddenPatterns: nil,
+		SkipHeaders:           nil,
+		MaxNesting:            defaultMaxNesting,
+		FS:                    nil,
+		Config:                "",
+		Pinned:                nil,
+		Overlay:               "",
+		DryRun:                false,
+		Check:                 false,
+		Strict:                false,
+		Gofmt:                 false,
+		List:                  false,
+		Print0:                false,
+		Diff:                  false,
+		Write:                 false,
+		Txtar:                 false,
+	}
+}
+
+// writes reports whether changed files are rewritten in place.
+func (o Options) writes() bool {
+	return !o.DryRun && !o.Check && (!o.Gofmt || o.Write)
+}
+
+func (o Options) validate() error {
+	if o.MinSize > 0 && o.MaxSize > 0 && o.MinSize > o.MaxSize {
+		return fmt.Errorf("minimum size %d exceeds maximum size %d", o.MinSize, o.MaxSize)
+	}
+
+	for _, pattern := range o.Excludes {
+		if err := validGlob(pattern); err != nil {
+			return fmt.Errorf("invalid exclude pattern %q: %w", pattern, err)
+		}
+	}
+
+	for _, pattern := range o.Packages {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid package pattern %q: %w", pattern, err)
+		}
+	}
+
+	for _, pattern := range o.IncludeHiddenPatterns {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid hidden directory pattern %q: %w", pattern, err)
+		}
+	}
+
+	for _, typ := range o.SkipTypes {
+		if dot := strings.LastIndex(typ, "."); dot <= 0 || dot == len(typ)-1 {
+			return fmt.Errorf("invalid skip type %q, want package.Type", typ)
+		}
+	}
+
+	if o.SkipTests && o.OnlyTests {
+		return errors.New("skip-tests and only-tests are mutually exclusive")
+	}
+
+	if o.MaxNesting < 0 {
+		return fmt.Errorf("maximum nesting must not be negative, got %d", o.MaxNesting)
+	}
+
+	if o.Workers < 0 {
+		return fmt.Errorf("workers must not be negative, got %d", o.Workers)
+	}
+
+	if o.MaxWriteConcurrency < 0 {
+		return fmt.Errorf("maximum write concurrency must not be negative, got %d", o.MaxWriteConcurrency)
+	}
+
+	if o.Watch && o.WatchInterval <= 0 {
+		return fmt.Errorf("watch interval must be positive, got %s", o.WatchInterval)
+	}
+
+	if o.Txtar && (o.List || o.Diff || o.Write) {
+		return errors.New("txtar cannot be combined with -l, -d or -w")
+	}
+
+	if o.Overlay != "" && !o.Txtar && o.writes() {
+		return errors.New("overlay cannot be combined with writing files; use -n, -check or gofmt mode without -w")
+	}
+
+	if o.FormatVersion < 1 || o.FormatVersion > reportSchemaVersion {
+		return fmt.Errorf("unsupported format version %d (supported: 1 to %d)", o.FormatVersion, reportSchemaVersion)
+	}
+
+	return nil
+}
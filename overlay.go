@@ -0,0 +1,147 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// errOverlaid is returned when writing a file whose contents come from the overlay.
+var errOverlaid = errors.New("file is overlaid")
+
+// loadOverlay reads an overlay file from fsys, a JSON object mapping file paths to the
+// contents that replace them, such as the unsaved buffers of an editor. Relative paths
+// are resolved against the working directory. The returned map is keyed by canonical
+// path.
+func loadOverlay(fsys FS, filename string) (map[string][]byte, error) {
+	data, err := fsys.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("read overlay: %w", err)
+	}
+
+	var contents map[string]string
+	if err := json.Unmarshal(data, &contents); err != nil {
+		return nil, fmt.Errorf("decode overlay %s: %w", filename, err)
+	}
+
+	overlay := make(map[string][]byte, len(contents))
+
+	for path, content := range contents {
+		canonical, err := canonicalPath(path)
+		if err != nil {
+			return nil, err
+		}
+
+		overlay[canonical] = []byte(content)
+	}
+
+	return overlay, nil
+}
+
+// overlayFS is an FS whose files are replaced by, or extended with, the contents of an
+// overlay. Overlaid files are never written.
+type overlayFS struct {
+	base    FS
+	overlay map[string][]byte
+	// loaded is the modification time of every overlaid file.
+	loaded time.Time
+}
+
+func newOverlayFS(base FS, overlay map[string][]byte) *overlayFS {
+	return &overlayFS{base: base, overlay: overlay, loaded: time.Now()}
+}
+
+// lookup returns the overlay contents of name.
+func (o *overlayFS) lookup(name string) ([]byte, bool) {
+	canonical, err := canonicalPath(name)
+	if err != nil {
+		return nil, false
+	}
+
+	content, ok := o.overlay[canonical]
+
+	return content, ok
+}
+
+func (o *overlayFS) ReadFile(name string) ([]byte, error) {
+	if content, ok := o.lookup(name); ok {
+		return content, nil
+	}
+
+	return o.base.ReadFile(name)
+}
+
+func (o *overlayFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	if _, ok := o.lookup(name); ok {
+		return fmt.Errorf("%s: %w", name, errOverlaid)
+	}
+
+	return o.base.WriteFile(name, data, perm)
+}
+
+func (o *overlayFS) Stat(name string) (fs.FileInfo, error) {
+	content, ok := o.lookup(name)
+	if !ok {
+		return o.base.Stat(name)
+	}
+
+	mode := fs.FileMode(0o644)
+	if info, err := o.base.Stat(name); err == nil {
+		mode = info.Mode()
+	}
+
+	return overlayInfo{name: filepath.Base(name), size: int64(len(content)), mode: mode, modTime: o.loaded}, nil
+}
+
+// ReadDir lists name in the base file system together with the overlaid files in it
+// that do not exist there.
+func (o *overlayFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	entries, err := o.base.ReadDir(name)
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := canonicalPath(name)
+	if err != nil {
+		return nil, err
+	}
+
+	for path := range o.overlay {
+		if filepath.Dir(path) != dir || slices.ContainsFunc(entries, func(e fs.DirEntry) bool { return e.Name() == filepath.Base(path) }) {
+			continue
+		}
+
+		info, err := o.Stat(filepath.Join(name, filepath.Base(path)))
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, fs.FileInfoToDirEntry(info))
+	}
+
+	slices.SortFunc(entries, func(a, b fs.DirEntry) int {
+		return strings.Compare(a.Name(), b.Name())
+	})
+
+	return entries, nil
+}
+
+// overlayInfo describes an overlaid file.
+type overlayInfo struct {
+	name    string
+	size    int64
+	mode    fs.FileMode
+	modTime time.Time
+}
+
+func (i overlayInfo) Name() string       { return i.name }
+func (i overlayInfo) Size() int64        { return i.size }
+func (i overlayInfo) Mode() fs.FileMode  { return i.mode }
+func (i overlayInfo) ModTime() time.Time { return i.modTime }
+func (i overlayInfo) IsDir() bool        { return false }
+func (i overlayInfo) Sys() any           { return nil }
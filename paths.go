@@ -0,0 +1,214 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// collectFiles resolves roots, each a Go file, a directory or a package pattern, to the
+// Go files to process. Paths are canonicalized by resolving them to absolute paths
+// without symbolic links, so overlapping roots (such as "." and "./pkg") and links to
+// the same file yield every file exactly once, under the first path it was found by.
+func (p *Processor) collectFiles(ctx context.Context, roots []string) ([]string, error) {
+	seen := map[string]bool{}
+	files := []string{}
+
+	for _, root := range roots {
+		found, err := p.resolveRoot(ctx, root)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, path := range found {
+			canonical, err := canonicalPath(path)
+			if err != nil {
+				return nil, err
+			}
+
+			if seen[canonical] {
+				continue
+			}
+
+			seen[canonical] = true
+			files = append(files, path)
+		}
+	}
+
+	return files, nil
+}
+
+func (p *Processor) resolveRoot(ctx context.Context, root string) ([]string, error) {
+	if isPackagePattern(p.fsys, root) {
+		return p.packageFiles(ctx, root)
+	}
+
+	info, err := p.fsys.Stat(root)
+	if err != nil {
+		return nil, fmt.Errorf("stat path: %w", err)
+	}
+
+	if info.IsDir() {
+		return p.walkDir(ctx, root)
+	}
+
+	if !strings.HasSuffix(root, ".go") {
+		return nil, fmt.Errorf("not a .go file: %s", root)
+	}
+
+	selected, err := p.selectFile(root, root, fs.FileInfoToDirEntry(info))
+	if err != nil || !selected {
+		return nil, err
+	}
+
+	return []string{root}, nil
+}
+
+// isPackagePattern reports whether arg names packages rather than a path, as in go vet:
+// a pattern containing "..." such as ./..., or an import path that does not exist as a
+// file or directory in fsys. Relative and absolute paths are always paths, so a mistyped one
+// fails instead of matching no packages.
+func isPackagePattern(fsys FS, arg string) bool {
+	if strings.Contains(arg, "...") {
+		return true
+	}
+
+	if strings.HasSuffix(arg, ".go") || isLocalPath(arg) {
+		return false
+	}
+
+	_, err := fsys.Stat(arg)
+
+	return errors.Is(err, fs.ErrNotExist)
+}
+
+// isLocalPath reports whether arg is a relative path starting with . or .., or an
+// absolute path, which the go command never takes for an import path.
+func isLocalPath(arg string) bool {
+	arg = filepath.ToSlash(arg)
+
+	return arg == "." || arg == ".." || strings.HasPrefix(arg, "./") || strings.HasPrefix(arg, "../") || filepath.IsAbs(arg) || strings.HasPrefix(arg, "/")
+}
+
+// packageFiles resolves a package pattern to the Go files of the matching packages,
+// including their tests, as selected by the build system for the current platform. It
+// fails if a package has errors or the pattern matches no packages. Generated test
+// mains, which live in the build cache, and any other files outside the module of
+// their package are left alone.
+func (p *Processor) packageFiles(ctx context.Context, pattern string) ([]string, error) {
+	ctx, span := p.tracer.start(ctx, "walk", "pattern", pattern)
+	defer span.finish()
+
+	cfg := &packages.Config{
+		Mode:    packages.NeedName | packages.NeedFiles | packages.NeedModule,
+		Context: ctx,
+		Tests:   true,
+		Overlay: p.overlay,
+	}
+
+	pkgs, err := packages.Load(cfg, pattern)
+	if err != nil {
+		span.fail(err)
+
+		return nil, fmt.Errorf("load packages %s: %w", pattern, err)
+	}
+
+	var (
+		files []string
+		errs  []error
+	)
+
+	reported := map[string]bool{}
+
+	for _, pkg := range pkgs {
+		// Test variants repeat the errors of the package they are built from.
+		for _, err := range pkg.Errors {
+			if msg := err.Error(); !reported[msg] {
+				reported[msg] = true
+
+				errs = append(errs, fmt.Errorf("package %s: %w", pkg.PkgPath, err))
+			}
+		}
+
+		if strings.HasSuffix(pkg.ID, ".test") {
+			continue
+		}
+
+		for _, file := range pkg.GoFiles {
+			if pkg.Module != nil && pkg.Module.Dir != "" && !inDir(pkg.Module.Dir, file) {
+				continue
+			}
+
+			info, err := p.fsys.Stat(file)
+			if err != nil {
+				return nil, fmt.Errorf("stat file: %w", err)
+			}
+
+			selected, err := p.selectFile(relPath(".", file), file, fs.FileInfoToDirEntry(info))
+			if err != nil {
+				return nil, err
+			}
+
+			if selected {
+				files = append(files, file)
+				p.importPaths.Store(file, pkg.PkgPath)
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		err := errors.Join(errs...)
+		span.fail(err)
+
+		return nil, fmt.Errorf("load packages %s: %w", pattern, err)
+	}
+
+	if len(pkgs) == 0 {
+		return nil, fmt.Errorf("pattern %s matched no packages", pattern)
+	}
+
+	return files, nil
+}
+
+// inDir reports whether file is inside dir.
+func inDir(dir, file string) bool {
+	rel, err := filepath.Rel(dir, file)
+
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// relPath returns target relative to base, or target itself if it has no relative
+// form, for matching against -exclude patterns.
+func relPath(base, target string) string {
+	if filepath.IsAbs(target) {
+		if abs, err := filepath.Abs(base); err == nil {
+			base = abs
+		}
+	}
+
+	rel, err := filepath.Rel(base, target)
+	if err != nil {
+		return target
+	}
+
+	return rel
+}
+
+func canonicalPath(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("resolve path: %w", err)
+	}
+
+	// A dangling link is kept as is; processing it reports the error.
+	if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+		return resolved, nil
+	}
+
+	return abs, nil
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestProcessPathsPackagePatternSkipsTestMain(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod":    "module example.com/m\n\ngo 1.24\n",
+		"a.go":      "package m\n\nvar A = `a`\n",
+		"a_test.go": "package m\n\nimport \"testing\"\n\nfunc TestA(t *testing.T) {}\n",
+	})
+	t.Chdir(dir)
+
+	opts := defaultOptions()
+	opts.DryRun = true
+
+	p, err := NewProcessor(opts, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rep, err := p.ProcessPaths(context.Background(), []string{"./..."}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rep.Processed != 2 {
+		t.Errorf("processed %d files, want 2", rep.Processed)
+	}
+
+	if len(rep.Files) != 1 || filepath.Base(rep.Files[0].Path) != "a.go" {
+		t.Errorf("changed files %+v, want only a.go", rep.Files)
+	}
+}
+
+func TestProcessPathsPackagePatternErrors(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod": "module example.com/m\n\ngo 1.24\n",
+	})
+	t.Chdir(dir)
+
+	opts := defaultOptions()
+	opts.DryRun = true
+
+	p, err := NewProcessor(opts, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, root := range []string{"./...", "./nonexistent", "example.com/m/nonexistent"} {
+		if _, err := p.ProcessPaths(context.Background(), []string{root}, 1); err == nil {
+			t.Errorf("ProcessPaths(%q) succeeded, want an error", root)
+		}
+	}
+}
+
+func TestProcessPathsFiltersNamedFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"a.go":            "package m\n\nvar A = `a`\n",
+		"a_test.go":       "package m\n\nvar B = `b`\n",
+		"zz_generated.go": "package m\n\nvar C = `c`\n",
+	})
+	t.Chdir(dir)
+
+	tests := []struct {
+		name      string
+		configure func(*Options)
+		paths     []string
+		want      int
+	}{
+		{name: "exclude", configure: func(o *Options) { o.Excludes = []string{"zz_*.go"} }, paths: []string{"zz_generated.go"}, want: 0},
+		{name: "skip tests", configure: func(o *Options) { o.SkipTests = true }, paths: []string{"a_test.go"}, want: 0},
+		{name: "only tests", configure: func(o *Options) { o.OnlyTests = true }, paths: []string{"a.go"}, want: 0},
+		{name: "only tests keeps tests", configure: func(o *Options) { o.OnlyTests = true }, paths: []string{"a_test.go"}, want: 1},
+		{name: "several files", configure: func(o *Options) { o.SkipTests = true }, paths: []string{"a.go", "a_test.go"}, want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := defaultOptions()
+			opts.DryRun = true
+			tt.configure(&opts)
+
+			p, err := NewProcessor(opts, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rep, err := p.ProcessPaths(context.Background(), tt.paths, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if rep.Processed != tt.want || len(rep.Files) != tt.want {
+				t.Errorf("ProcessPaths(%q) processed %d files and changed %d, want %d", tt.paths, rep.Processed, len(rep.Files), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package quotedconv
+
+import (
+	"go/ast"
+	"go/token"
+	"path"
+	"strconv"
+)
+
+// patternCalls are the functions, by import path and name, whose string arguments are
+// regular expressions or templates. Those are raw on purpose and are left alone.
+var patternCalls = map[string]bool{
+	"regexp.Compile":          true,
+	"regexp.CompilePOSIX":     true,
+	"regexp.Match":            true,
+	"regexp.MatchReader":      true,
+	"regexp.MatchString":      true,
+	"regexp.MustCompile":      true,
+	"regexp.MustCompilePOSIX": true,
+}
+
+// templatePackages are the packages whose Template.Parse arguments are templates.
+var templatePackages = map[string]bool{
+	"text/template": true,
+	"html/template": true,
+}
+
+// callArgTargets returns the positions of the string literals passed to calls whose
+// arguments are never converted. Calls are matched syntactically: pkg.F(...) by the
+// import path or name of pkg, F(...) as a function of the file's own package, and
+// x.Parse(...) when x is a chain of calls rooted in a template package, such as
+// template.New("t").Funcs(m).Parse(...).
+func (c *Converter) callArgTargets(file *ast.File) map[token.Pos]bool {
+	if !c.opts.PatternCalls && len(c.skipCalls) == 0 {
+		return nil
+	}
+
+	imports := importNames(file)
+	targets := map[token.Pos]bool{}
+
+	ast.Inspect(file, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+
+		skip := false
+
+		switch fun := call.Fun.(type) {
+		case *ast.Ident:
+			skip = c.skipCalls[file.Name.Name+"."+fun.Name]
+		case *ast.SelectorExpr:
+			if pkg, ok := fun.X.(*ast.Ident); ok && imports[pkg.Name] != "" {
+				qualified := imports[pkg.Name] + "." + fun.Sel.Name
+				skip = c.opts.PatternCalls && patternCalls[qualified] || c.skipCalls[qualified] || c.skipCalls[pkg.Name+"."+fun.Sel.Name]
+			} else if fun.Sel.Name == "Parse" {
+				skip = c.opts.PatternCalls && templatePackages[imports[callChainRoot(fun.X)]]
+			}
+		}
+
+		if skip {
+			for _, arg := range call.Args {
+				markLiterals(arg, targets)
+			}
+		}
+
+		return true
+	})
+
+	return targets
+}
+
+// importNames maps the names files refer to imported packages by to their import
+// paths. Packages are assumed to be named after the last element of their path.
+func importNames(file *ast.File) map[string]string {
+	names := make(map[string]string, len(file.Imports))
+
+	for _, spec := range file.Imports {
+		importPath, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+
+		name := path.Base(importPath)
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+
+		names[name] = importPath
+	}
+
+	return names
+}
+
+// callChainRoot returns the identifier a chain of method calls such as
+// template.New("t").Funcs(m) starts from, or "" if expr is not such a chain.
+func callChainRoot(expr ast.Expr) string {
+	for {
+		switch e := expr.(type) {
+		case *ast.CallExpr:
+			expr = e.Fun
+		case *ast.SelectorExpr:
+			expr = e.X
+		case *ast.Ident:
+			return e.Name
+		default:
+			return ""
+		}
+	}
+}
+
+// markLiterals records the string literals making up expr, looking through
+// parentheses and concatenations.
+func markLiterals(expr ast.Expr, targets map[token.Pos]bool) {
+	switch e := expr.(type) {
+	case *ast.BasicLit:
+		if e.Kind == token.STRING {
+			targets[e.Pos()] = true
+		}
+	case *ast.ParenExpr:
+		markLiterals(e.X, targets)
+	case *ast.BinaryExpr:
+		if e.Op == token.ADD {
+			markLiterals(e.X, targets)
+			markLiterals(e.Y, targets)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package quotedconv_test
+
+import (
+	"testing"
+
+	"github.com/otakakot/quotedconv/quotedconv"
+)
+
+func TestConvertPatternCalls(t *testing.T) {
+	tests := []conversionTest{
+		{
+			name: "regexp",
+			src:  "import \"regexp\"\n\nvar re = regexp.MustCompile(`a+`)\nvar s = `s`\n",
+			want: []string{"`s`"},
+		},
+		{
+			name: "renamed import",
+			src:  "import re \"regexp\"\n\nvar r = re.Compile(`a+`)\n",
+			want: []string{},
+		},
+		{
+			name: "concatenated argument",
+			src:  "import \"regexp\"\n\nvar re = regexp.MustCompile(`a` + (`b`))\n",
+			want: []string{},
+		},
+		{
+			name: "nested call",
+			src:  "import (\n\t\"fmt\"\n\t\"regexp\"\n)\n\nvar s = fmt.Sprint(`s`, regexp.MustCompile(`a+`))\n",
+			want: []string{"`s`"},
+		},
+		{
+			name: "template chain",
+			src:  "import \"text/template\"\n\nvar t = template.Must(template.New(`t`).Funcs(nil).Parse(`{{.}}`))\n",
+			want: []string{"`t`"},
+		},
+		{
+			name: "html template",
+			src:  "import \"html/template\"\n\nvar t = template.Must(template.New(\"t\").Parse(`<p>{{.}}</p>`))\n",
+			want: []string{},
+		},
+		{
+			name: "parse outside template packages",
+			src:  "import \"net/url\"\n\nvar u, _ = url.Parse(`https://example.com`)\n",
+			want: []string{"`https://example.com`"},
+		},
+		{
+			name: "parse chain of another root",
+			src:  "import \"text/template\"\n\nvar t = newT().Parse(`{{.}}`)\n\nfunc newT() *template.Template { return nil }\n",
+			want: []string{"`{{.}}`"},
+		},
+		{
+			name: "package not imported",
+			src:  "var regexp struct{ MustCompile func(string) any }\n\nvar re = regexp.MustCompile(`a+`)\n",
+			want: []string{"`a+`"},
+		},
+	}
+
+	runConversionTests(t, quotedconv.DefaultOptions(), tests)
+
+	t.Run("disabled", func(t *testing.T) {
+		opts := quotedconv.DefaultOptions()
+		opts.PatternCalls = false
+
+		got := converted(t, opts, "import \"regexp\"\n\nvar re = regexp.MustCompile(`a+`)\n")
+		if len(got) != 1 {
+			t.Errorf("converted %q, want the regexp", got)
+		}
+	})
+}
@@ -0,0 +1,93 @@
+package quotedconv
+
+import (
+	"bytes"
+	"errors"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"slices"
+	"strconv"
+)
+
+var errCgoPreambleChanged = errors.New("cgo preamble changed")
+
+// isCgoImport reports whether decl is an import declaration that imports "C". The
+// literals of such declarations are never rewritten, so their C preamble and the
+// #cgo directives in it cannot be affected by a conversion.
+func isCgoImport(decl *ast.GenDecl) bool {
+	if decl.Tok != token.IMPORT {
+		return false
+	}
+
+	for _, spec := range decl.Specs {
+		if imp, ok := spec.(*ast.ImportSpec); ok && importsC(imp) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// importsC reports whether imp imports "C", whichever quotes its path is written with.
+func importsC(imp *ast.ImportSpec) bool {
+	path, err := strconv.Unquote(imp.Path.Value)
+
+	return err == nil && path == "C"
+}
+
+// cgoPreambles returns the source bytes of every comment attached to an import of "C".
+func cgoPreambles(src []byte) ([][]byte, error) {
+	fset := token.NewFileSet()
+
+	file, err := parser.ParseFile(fset, "", src, parser.ImportsOnly|parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	if !slices.ContainsFunc(file.Imports, importsC) {
+		return nil, nil
+	}
+
+	var preambles [][]byte
+
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || !isCgoImport(gen) {
+			continue
+		}
+
+		docs := []*ast.CommentGroup{gen.Doc}
+		for _, spec := range gen.Specs {
+			docs = append(docs, spec.(*ast.ImportSpec).Doc)
+		}
+
+		for _, doc := range docs {
+			if doc != nil {
+				preambles = append(preambles, src[fset.Position(doc.Pos()).Offset:fset.Position(doc.End()).Offset])
+			}
+		}
+	}
+
+	return preambles, nil
+}
+
+// verifyCgoPreambles ensures that rewriting src into out left every cgo preamble
+// byte-identical and attached to its import "C".
+func verifyCgoPreambles(src, out []byte) error {
+	before, err := cgoPreambles(src)
+	if err != nil {
+		return err
+	}
+
+	after, err := cgoPreambles(out)
+	if err != nil {
+		return err
+	}
+
+	if !slices.EqualFunc(before, after, bytes.Equal) {
+		return errCgoPreambleChanged
+	}
+
+	return nil
+}
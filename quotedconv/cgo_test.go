@@ -0,0 +1,56 @@
+package quotedconv_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/otakakot/quotedconv/quotedconv"
+)
+
+func TestProcessLeavesCgoPreambles(t *testing.T) {
+	tests := []struct {
+		name     string
+		preamble string
+	}{
+		{
+			name: "line comments",
+			preamble: "// #cgo CFLAGS: -DGREETING=`hello`\n" +
+				"// #include <stdio.h>\n" +
+				"import `C`\n",
+		},
+		{
+			name: "grouped block comment",
+			preamble: "import (\n" +
+				"\t/*\n" +
+				"\t#cgo LDFLAGS: -lm\n" +
+				"\t#define RAW `x`\n" +
+				"\t*/\n" +
+				"\t`C`\n" +
+				"\t`fmt`\n" +
+				")\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := "package x\n\n" + tt.preamble + "\nvar s = `hello`\n\nvar _ = fmt.Sprint\n"
+
+			out, changed, err := quotedconv.Process([]byte(src))
+			if err != nil {
+				t.Fatalf("Process: %v", err)
+			}
+
+			if !changed {
+				t.Fatal("Process reported no change")
+			}
+
+			if !strings.HasPrefix(string(out), "package x\n\n"+tt.preamble) {
+				t.Errorf("preamble changed:\n%s", out)
+			}
+
+			if !strings.Contains(string(out), "var s = \"hello\"\n") {
+				t.Errorf("literal outside the preamble not converted:\n%s", out)
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package quotedconv
+
+import (
+	"fmt"
+	"go/token"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// sqlStatement matches content starting with an SQL statement keyword followed by more
+// of the statement.
+var sqlStatement = regexp.MustCompile(`(?i)^\s*(?:SELECT|INSERT|UPDATE|DELETE|UPSERT|REPLACE|MERGE|WITH|CREATE|ALTER|DROP|TRUNCATE|GRANT|REVOKE)\s+\S`)
+
+// urlLike matches content that is a single URL with a scheme.
+var urlLike = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9+.-]*://\S+$`)
+
+// pathLike matches content that is a single filesystem path: a Windows path with a drive
+// letter, a UNC path, or a relative or absolute path with backslash or slash separators.
+var pathLike = regexp.MustCompile(`^(?:[A-Za-z]:[\\/]|\\\\|\.{1,2}[\\/]|~/|/)[^\s]*$|^[^\s\\]+(?:\\[^\s\\]+)+$`)
+
+// looksLikePath reports whether content reads like a URL or a filesystem path.
+func looksLikePath(content string) bool {
+	return urlLike.MatchString(content) || pathLike.MatchString(content)
+}
+
+// looksLikeSQL reports whether content reads like an SQL statement.
+func looksLikeSQL(content string) bool {
+	return sqlStatement.MatchString(content)
+}
+
+// looksLikeJSON reports whether content reads like a JSON object or array: it is
+// enclosed in braces or brackets and contains quoted strings.
+func looksLikeJSON(content string) bool {
+	trimmed := strings.TrimSpace(content)
+	if len(trimmed) < 2 || !strings.Contains(trimmed, `"`) {
+		return false
+	}
+
+	first, last := trimmed[0], trimmed[len(trimmed)-1]
+
+	return first == '{' && last == '}' || first == '[' && last == ']'
+}
+
+// checkRunes applies c.opts.Invisible and c.opts.ControlChars to content, the content of a
+// raw literal at pos that is about to be converted; hasCR reports carriage returns in
+// its source, which the scanner strips from content. It reports whether the literal is
+// to be left alone, or an error if the file must fail.
+func (c *Converter) checkRunes(pos token.Position, content string, hasCR bool) (bool, error) {
+	opts := c.opts
+
+	if r, ok := findRune(content, func(r rune) bool { return unicode.Is(unicode.Cf, r) }); ok {
+		switch opts.Invisible {
+		case CharPolicySkip:
+			c.logger.Printf("Warning: %s: raw string literal contains invisible character %U; it is left unconverted", pos, r)
+
+			return true, nil
+		case CharPolicyError:
+			return false, fmt.Errorf("%s: raw string literal contains invisible character %U", pos, r)
+		default:
+			c.logger.Printf("Warning: %s: raw string literal contains invisible character %U; it is escaped by the conversion", pos, r)
+		}
+	}
+
+	r, ok := findRune(content, unicode.IsControl)
+	if hasCR {
+		r, ok = '\r', true
+	}
+
+	if !ok {
+		return false, nil
+	}
+
+	switch opts.ControlChars {
+	case CharPolicySkip:
+		c.logger.Printf("Warning: %s: raw string literal contains control character %U; it is left unconverted", pos, r)
+
+		return true, nil
+	case CharPolicyError:
+		return false, fmt.Errorf("%s: raw string literal contains control character %U", pos, r)
+	}
+
+	// Escaping control characters such as tabs is the conversion's purpose; only
+	// carriage returns, which are not part of the value, deserve a warning.
+	if hasCR {
+		c.logger.Printf("Warning: %s: raw string literal contains carriage returns, which are not part of its value; they are dropped by the conversion", pos)
+	}
+
+	return false, nil
+}
+
+// findRune returns the first rune in s satisfying f.
+func findRune(s string, f func(rune) bool) (rune, bool) {
+	i := strings.IndexFunc(s, f)
+	if i < 0 {
+		return 0, false
+	}
+
+	r, _ := utf8.DecodeRuneInString(s[i:])
+
+	return r, true
+}
+
+// readabilityCap returns why opts.ReadabilityCap keeps the raw literal value from being
+// converted to converted, or "" if it does not: long literals are much harder to read
+// with escapes than without.
+func readabilityCap(value, converted string, opts Options) string {
+	if opts.ReadabilityCap <= 0 || strings.Contains(value, "\n") {
+		return ""
+	}
+
+	n, escapes := utf8.RuneCountInString(value)-2, countEscapes(converted)
+	if n <= opts.ReadabilityCap || escapes == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("%d characters would need %d escapes, above the readability cap of %d characters", n, escapes, opts.ReadabilityCap)
+}
+
+// keepRaw reports whether the content of a raw literal is left alone: it is outside
+// opts.MinLen and opts.MaxLen, or looks like a payload that reads better raw, as
+// selected by opts.KeepSQL, opts.KeepJSON and opts.KeepPaths.
+func keepRaw(content string, opts Options) bool {
+	if n := utf8.RuneCountInString(content); n < opts.MinLen || opts.MaxLen > 0 && n > opts.MaxLen {
+		return true
+	}
+
+	return opts.KeepSQL && looksLikeSQL(content) || opts.KeepJSON && looksLikeJSON(content) || opts.KeepPaths && looksLikePath(content)
+}
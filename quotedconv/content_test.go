@@ -0,0 +1,187 @@
+package quotedconv_test
+
+import (
+	"testing"
+
+	"github.com/otakakot/quotedconv/quotedconv"
+)
+
+func TestConvertLiteralKeepSQLAndJSON(t *testing.T) {
+	opts := quotedconv.DefaultOptions()
+	opts.QuotePolicy = quotedconv.QuotePolicyEscape
+	opts.KeepSQL = true
+	opts.KeepJSON = true
+
+	runLiteralTests(t, opts, []literalTest{
+		{value: "`SELECT id FROM t`", want: ""},
+		{value: "`select 1`", want: ""},
+		{value: "`  WITH x AS (SELECT 1) SELECT * FROM x`", want: ""},
+		{value: "`DELETE FROM t WHERE id = 1`", want: ""},
+		{value: "`SELECTED items`", want: `"SELECTED items"`},
+		{value: "`DROP`", want: `"DROP"`},
+		{value: "`{\"a\": 1}`", want: ""},
+		{value: "` [\"a\", \"b\"] `", want: ""},
+		{value: "`[1, 2]`", want: `"[1, 2]"`},
+		{value: "`{\"a\"`", want: `"{\"a\""`},
+		{value: "`say \"hi\"`", want: `"say \"hi\""`},
+	})
+
+	opts.KeepSQL = false
+	opts.KeepJSON = false
+
+	runLiteralTests(t, opts, []literalTest{
+		{value: "`SELECT id FROM t`", want: `"SELECT id FROM t"`},
+		{value: "`{\"a\": 1}`", want: `"{\"a\": 1}"`},
+	})
+}
+
+func TestConvertLiteralLengthBounds(t *testing.T) {
+	opts := quotedconv.DefaultOptions()
+	opts.MinLen = 3
+	opts.MaxLen = 5
+
+	runLiteralTests(t, opts, []literalTest{
+		{value: "``", want: ""},
+		{value: "`ab`", want: ""},
+		{value: "`abc`", want: `"abc"`},
+		{value: "`abcde`", want: `"abcde"`},
+		{value: "`abcdef`", want: ""},
+		// Lengths count characters, not bytes.
+		{value: "`éèà`", want: `"éèà"`},
+		{value: "`éèàçùœ`", want: ""},
+	})
+
+	opts.MinLen = 0
+	opts.MaxLen = 0
+
+	runLiteralTests(t, opts, []literalTest{
+		{value: "``", want: `""`},
+		{value: "`abcdef`", want: `"abcdef"`},
+	})
+}
+
+func TestConvertLiteralKeepPaths(t *testing.T) {
+	opts := quotedconv.DefaultOptions()
+	opts.EscapeBackslashes = true
+	opts.KeepPaths = true
+
+	runLiteralTests(t, opts, []literalTest{
+		{value: "`C:\\temp`", want: ""},
+		{value: "`C:/temp`", want: ""},
+		{value: "`\\\\server\\share`", want: ""},
+		{value: "`a\\b\\c`", want: ""},
+		{value: "`./bin/app`", want: ""},
+		{value: "`../x`", want: ""},
+		{value: "`~/x`", want: ""},
+		{value: "`/usr/bin`", want: ""},
+		{value: "`https://example.com/x?y=1`", want: ""},
+		{value: "`a/b`", want: `"a/b"`},
+		{value: "`\\d+`", want: `"\\d+"`},
+		{value: "`see C:\\temp`", want: `"see C:\\temp"`},
+		{value: "`http://x y`", want: `"http://x y"`},
+	})
+
+	// The path heuristic is independent of the others.
+	opts.KeepPaths = false
+	opts.KeepSQL = true
+
+	runLiteralTests(t, opts, []literalTest{
+		{value: "`C:\\temp`", want: `"C:\\temp"`},
+	})
+}
+
+// charPolicyTest is a case for a character policy: want is the converted literal, "" if
+// the literal is left alone, and wantErr whether the file fails.
+type charPolicyTest struct {
+	policy  quotedconv.CharPolicy
+	want    string
+	wantErr bool
+}
+
+func runCharPolicyTests(t *testing.T, src string, set func(*quotedconv.Options, quotedconv.CharPolicy), tests []charPolicyTest) {
+	t.Helper()
+
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			opts := quotedconv.DefaultOptions()
+			set(&opts, tt.policy)
+
+			result, err := convert(t, opts, src)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Convert error = %v, want error %t", err, tt.wantErr)
+			}
+
+			got := ""
+			if len(result.Changes) > 0 {
+				got = result.Changes[0].After
+			}
+
+			if got != tt.want {
+				t.Errorf("converted to %s, want %s", orUnchanged(got), orUnchanged(tt.want))
+			}
+		})
+	}
+}
+
+func TestConvertInvisiblePolicy(t *testing.T) {
+	setInvisible := func(opts *quotedconv.Options, p quotedconv.CharPolicy) { opts.Invisible = p }
+
+	runCharPolicyTests(t, "var s = `a\u202eb`\n", setInvisible, []charPolicyTest{
+		{policy: quotedconv.CharPolicyEscape, want: `"a\u202eb"`},
+		{policy: quotedconv.CharPolicySkip, want: ""},
+		{policy: quotedconv.CharPolicyError, wantErr: true},
+	})
+
+	// Zero-width runes are invisible too.
+	runCharPolicyTests(t, "var s = `a\u200db`\n", setInvisible, []charPolicyTest{
+		{policy: quotedconv.CharPolicySkip, want: ""},
+	})
+}
+
+func TestConvertControlCharsPolicy(t *testing.T) {
+	setControlChars := func(opts *quotedconv.Options, p quotedconv.CharPolicy) { opts.ControlChars = p }
+
+	runCharPolicyTests(t, "var s = `a\tb`\n", setControlChars, []charPolicyTest{
+		{policy: quotedconv.CharPolicyEscape, want: `"a\tb"`},
+		{policy: quotedconv.CharPolicySkip, want: ""},
+		{policy: quotedconv.CharPolicyError, wantErr: true},
+	})
+
+	runCharPolicyTests(t, "var s = `a\x01b`\n", setControlChars, []charPolicyTest{
+		{policy: quotedconv.CharPolicyEscape, want: `"a\x01b"`},
+		{policy: quotedconv.CharPolicySkip, want: ""},
+	})
+
+	// Carriage returns are not part of the value, so escaping drops them.
+	runCharPolicyTests(t, "var s = `a\rb`\n", setControlChars, []charPolicyTest{
+		{policy: quotedconv.CharPolicyEscape, want: `"ab"`},
+		{policy: quotedconv.CharPolicySkip, want: ""},
+		{policy: quotedconv.CharPolicyError, wantErr: true},
+	})
+}
+
+func TestConvertReadabilityCap(t *testing.T) {
+	opts := quotedconv.DefaultOptions()
+	opts.ReadabilityCap = 10
+	opts.EscapeBackslashes = true
+
+	runConversionTests(t, opts, []conversionTest{
+		{name: "short", src: "var s = `tab\there`\n", want: []string{"`tab\there`"}},
+		{name: "long with escapes", src: "var s = `a long line\twith a tab`\n", want: []string{}},
+		{name: "long path", src: "var s = `C:\\some\\long\\path`\n", want: []string{}},
+		{name: "long without escapes", src: "var s = `a long line without escapes`\n", want: []string{"`a long line without escapes`"}},
+		{name: "forced", src: "//quotedconv:force\nvar s = `a long line\twith a tab`\n", want: []string{"`a long line\twith a tab`"}},
+	})
+
+	opts.ReportSuppressed = true
+
+	result, err := convert(t, opts, "var s = `a long line\twith a tab`\n")
+	if err != nil {
+		t.Fatalf("Convert: %v", err)
+	}
+
+	const want = "22 characters would need 1 escapes, above the readability cap of 10 characters"
+	if len(result.Suppressed) != 1 || result.Suppressed[0].Reason != want {
+		t.Errorf("suppressed %v, want one with reason %q", result.Suppressed, want)
+	}
+}
@@ -0,0 +1,68 @@
+package quotedconv
+
+// Logger receives the warnings and notes of a Converter. *log.Logger implements it.
+type Logger interface {
+	Printf(format string, args ...any)
+}
+
+// Option configures a Converter built by New.
+type Option func(*Converter)
+
+// WithOptions replaces all conversion options with opts; options given after it
+// adjust the result.
+func WithOptions(opts Options) Option {
+	return func(c *Converter) {
+		c.opts = opts
+	}
+}
+
+// WithHeuristics replaces the heuristics leaving literals that are likely raw on
+// purpose untouched.
+func WithHeuristics(h Heuristics) Option {
+	return func(c *Converter) {
+		c.opts.Heuristics = h
+	}
+}
+
+// WithWorkers sets the number of files ProcessFS converts at once. The default is the
+// number of CPUs.
+func WithWorkers(n int) Option {
+	return func(c *Converter) {
+		c.workers = n
+	}
+}
+
+// WithDryRun makes ProcessFS report the changes without writing to a WriteFS.
+func WithDryRun() Option {
+	return func(c *Converter) {
+		c.dryRun = true
+	}
+}
+
+// OnConvert calls f for every change the Converter is about to make; the change is
+// only made if f returns true. It lets callers apply their own policy on top of the
+// options, such as consulting an allowlist. A vetoed literal counts as suppressed. f
+// may be called from several goroutines at once.
+func OnConvert(f func(Change) bool) Option {
+	return func(c *Converter) {
+		c.onConvert = f
+	}
+}
+
+// WithOverlay makes ProcessFS read the files named in overlay from it instead of the
+// file system, as for the unsaved buffers of an editor; files that do not exist in the
+// file system are added. Names are slash-separated paths in the file system, as
+// accepted by fs.ValidPath. Overlaid files are never written back.
+func WithOverlay(overlay map[string][]byte) Option {
+	return func(c *Converter) {
+		c.overlay = overlay
+	}
+}
+
+// WithLogger sends the warnings and notes of the Converter to l instead of the
+// standard logger; nil discards them.
+func WithLogger(l Logger) Option {
+	return func(c *Converter) {
+		c.logger = l
+	}
+}
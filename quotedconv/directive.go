@@ -0,0 +1,173 @@
+package quotedconv
+
+import (
+	"bytes"
+	"errors"
+	"go/ast"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// directiveForce forces the conversion of the next raw string literal, regardless of
+// the heuristics that would otherwise leave it alone.
+const directiveForce = "//quotedconv:force"
+
+// directiveIgnore leaves the next string literal untouched, even if it would be
+// converted. It takes precedence over directiveForce.
+const directiveIgnore = "//quotedconv:ignore"
+
+// directiveFileIgnore skips the whole file. It must appear before the package clause
+// or trail it on the same line.
+const directiveFileIgnore = "//quotedconv:file-ignore"
+
+// ErrFileIgnored reports a file carrying directiveFileIgnore.
+var ErrFileIgnored = errors.New("file ignored by directive")
+
+// directiveEnable, anywhere in a file, opts the file in when opts.RequireEnable is set.
+const directiveEnable = "//quotedconv:enable"
+
+// isDirective reports whether the comment text is the directive, optionally followed
+// by an explanation.
+func isDirective(text, directive string) bool {
+	rest, ok := strings.CutPrefix(text, directive)
+
+	return ok && (rest == "" || rest[0] == ' ' || rest[0] == '\t')
+}
+
+// directiveTargets returns the positions of the string literals that a directive
+// applies to. A directive trailing a literal on the same line applies to that literal;
+// otherwise it applies to the next string literal, if that starts on the directive's
+// line or the line after.
+func directiveTargets(fset *token.FileSet, file *ast.File, directive string) map[token.Pos]bool {
+	var comments []*ast.Comment
+
+	for _, group := range file.Comments {
+		for _, c := range group.List {
+			if isDirective(c.Text, directive) {
+				comments = append(comments, c)
+			}
+		}
+	}
+
+	if len(comments) == 0 {
+		return nil
+	}
+
+	var lits []*ast.BasicLit
+
+	ast.Inspect(file, func(n ast.Node) bool {
+		if lit, ok := n.(*ast.BasicLit); ok && lit.Kind == token.STRING {
+			lits = append(lits, lit)
+		}
+
+		return true
+	})
+
+	slices.SortFunc(lits, func(a, b *ast.BasicLit) int {
+		return int(a.Pos() - b.Pos())
+	})
+
+	targets := make(map[token.Pos]bool, len(comments))
+
+	for _, c := range comments {
+		line := fset.Position(c.Pos()).Line
+
+		i, _ := slices.BinarySearchFunc(lits, c.Pos(), func(lit *ast.BasicLit, pos token.Pos) int {
+			return int(lit.Pos() - pos)
+		})
+
+		switch {
+		case i > 0 && fset.Position(lits[i-1].End()).Line == line:
+			targets[lits[i-1].Pos()] = true
+		case i < len(lits) && fset.Position(lits[i].Pos()).Line-line <= 1:
+			targets[lits[i].Pos()] = true
+		}
+	}
+
+	return targets
+}
+
+// hasFileIgnore reports whether src carries directiveFileIgnore near its package clause.
+func hasFileIgnore(src []byte) bool {
+	if !bytes.Contains(src, []byte(directiveFileIgnore)) {
+		return false
+	}
+
+	fset := token.NewFileSet()
+
+	file, err := parser.ParseFile(fset, "", src, parser.PackageClauseOnly|parser.ParseComments)
+	if err != nil {
+		// The full parse reports the error.
+		return false
+	}
+
+	line := fset.Position(file.Name.End()).Line
+
+	for _, group := range file.Comments {
+		for _, c := range group.List {
+			if fset.Position(c.Pos()).Line > line {
+				return false
+			}
+
+			if isDirective(c.Text, directiveFileIgnore) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// hasEnable reports whether src has a directiveEnable comment. It only scans tokens, so
+// the directive is not mistaken for the content of a string literal.
+func hasEnable(src []byte) bool {
+	if !bytes.Contains(src, []byte(directiveEnable)) {
+		return false
+	}
+
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(src))
+
+	var s scanner.Scanner
+	s.Init(file, src, nil, scanner.ScanComments)
+
+	for {
+		_, tok, lit := s.Scan()
+
+		switch {
+		case tok == token.EOF:
+			return false
+		case tok == token.COMMENT && isDirective(lit, directiveEnable):
+			return true
+		}
+	}
+}
+
+// forceConvertLiteral converts a raw string literal to an interpreted one no matter
+// what it contains.
+func forceConvertLiteral(value string, opts Options) (string, bool) {
+	if !isRawLiteral(value) {
+		return "", false
+	}
+
+	content, err := strconv.Unquote(value)
+	if err != nil {
+		return "", false
+	}
+
+	return opts.Escaping.quote(content), true
+}
+
+// collapseLines merges the lines spanned by a multi-line literal that was rewritten to
+// a single line, given as its first and last line, so the printer neither moves
+// comments trailing the literal to a line of their own nor inserts blank lines after
+// it. Ranges must be collapsed from the end of the file backwards.
+func collapseLines(tf *token.File, first, last int) {
+	for range last - first {
+		tf.MergeLine(first)
+	}
+}
@@ -0,0 +1,52 @@
+package quotedconv_test
+
+import (
+	"testing"
+
+	"github.com/otakakot/quotedconv/quotedconv"
+)
+
+func TestConvertIgnoreDirective(t *testing.T) {
+	runConversionTests(t, quotedconv.DefaultOptions(), []conversionTest{
+		{
+			name: "same line",
+			src:  "var a = `a` //quotedconv:ignore\nvar b = `b`\n",
+			want: []string{"`b`"},
+		},
+		{
+			name: "line above",
+			src:  "//quotedconv:ignore\nvar a = `a`\nvar b = `b`\n",
+			want: []string{"`b`"},
+		},
+		{
+			name: "with an explanation",
+			src:  "//quotedconv:ignore: kept raw for grep\nvar a = `a`\n",
+			want: []string{"`a`"},
+		},
+		{
+			name: "with a spaced explanation",
+			src:  "//quotedconv:ignore kept raw for grep\nvar a = `a`\n",
+			want: []string{},
+		},
+		{
+			name: "blank line in between",
+			src:  "//quotedconv:ignore\n\nvar a = `a`\n",
+			want: []string{"`a`"},
+		},
+		{
+			name: "only the next literal",
+			src:  "//quotedconv:ignore\nvar a, b = `a`, `b`\n",
+			want: []string{"`b`"},
+		},
+		{
+			name: "similar comment",
+			src:  "//quotedconv:ignored\nvar a = `a`\n",
+			want: []string{"`a`"},
+		},
+		{
+			name: "over force",
+			src:  "//quotedconv:force\nvar a = `a\\b` //quotedconv:ignore\n",
+			want: []string{},
+		},
+	})
+}
@@ -0,0 +1,112 @@
+package quotedconv
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"unicode/utf16"
+)
+
+// TextEdit replaces the bytes of a source from offset Start up to offset End with
+// NewText.
+type TextEdit struct {
+	Start   int
+	End     int
+	NewText string
+}
+
+// Edit returns the edit applying c to the original source.
+func (c Change) Edit() TextEdit {
+	return TextEdit{Start: c.Pos.Offset, End: c.End.Offset, NewText: c.After}
+}
+
+// ConvertAt converts only the string literal of src containing offset, with the
+// default options. See Converter.ConvertAt.
+func ConvertAt(src []byte, offset int) (edit TextEdit, ok bool, err error) {
+	c, err := New()
+	if err != nil {
+		return TextEdit{Start: 0, End: 0, NewText: ""}, false, err
+	}
+
+	return c.ConvertAt(src, offset)
+}
+
+// ConvertAt returns the edit converting the string literal of src containing offset,
+// for quick fixes at the cursor of an editor. The literal is subject to the same
+// options and directives as when the whole file is converted; ok is false if offset
+// is not within a literal that would be converted.
+func (c *Converter) ConvertAt(src []byte, offset int) (edit TextEdit, ok bool, err error) {
+	edit = TextEdit{Start: 0, End: 0, NewText: ""}
+
+	if offset < 0 || offset > len(src) {
+		return edit, false, fmt.Errorf("offset %d out of range [0, %d]", offset, len(src))
+	}
+
+	result, err := c.Convert(context.Background(), "", src)
+	if errors.Is(err, ErrFileIgnored) {
+		return edit, false, nil
+	}
+
+	if err != nil {
+		return edit, false, err
+	}
+
+	for _, change := range result.Changes {
+		if change.Pos.Offset <= offset && offset < change.End.Offset {
+			return change.Edit(), true, nil
+		}
+	}
+
+	return edit, false, nil
+}
+
+// Position is a position in a text document as in the Language Server Protocol: a
+// zero-based line and a zero-based character offset in UTF-16 code units.
+type Position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+// Range is a half-open range of a text document as in the Language Server Protocol.
+type Range struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+// LSPTextEdit is a text edit as in the Language Server Protocol.
+type LSPTextEdit struct {
+	Range   Range  `json:"range"`
+	NewText string `json:"newText"`
+}
+
+// LSP returns e as a Language Server Protocol text edit on src, the source e applies
+// to.
+func (e TextEdit) LSP(src []byte) LSPTextEdit {
+	return LSPTextEdit{Range: Range{Start: lspPosition(src, e.Start), End: lspPosition(src, e.End)}, NewText: e.NewText}
+}
+
+// LSPEdits returns changes as Language Server Protocol text edits on src, the original
+// source they were found in. The edits do not overlap, so they can be applied to an
+// open buffer together.
+func LSPEdits(src []byte, changes []Change) []LSPTextEdit {
+	edits := make([]LSPTextEdit, 0, len(changes))
+	for _, c := range changes {
+		edits = append(edits, c.Edit().LSP(src))
+	}
+
+	return edits
+}
+
+// lspPosition returns the Language Server Protocol position of offset in src.
+func lspPosition(src []byte, offset int) Position {
+	offset = min(max(offset, 0), len(src))
+	lineStart := bytes.LastIndexByte(src[:offset], '\n') + 1
+
+	character := 0
+	for _, r := range string(src[lineStart:offset]) {
+		character += max(utf16.RuneLen(r), 1)
+	}
+
+	return Position{Line: bytes.Count(src[:offset], []byte{'\n'}), Character: character}
+}
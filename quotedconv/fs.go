@@ -0,0 +1,176 @@
+package quotedconv
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// WriteFS is a file system converted files can be written back to.
+type WriteFS interface {
+	fs.FS
+	WriteFile(name string, data []byte, perm fs.FileMode) error
+}
+
+// FileResult is the conversion of a file found by ProcessFS.
+type FileResult struct {
+	// Path is the slash-separated name of the file in the file system.
+	Path string
+	Result
+}
+
+// ProcessFS converts every Go file in fsys with a Converter built by New from opts. It
+// is shorthand for calling the Converter's ProcessFS method.
+func ProcessFS(ctx context.Context, fsys fs.FS, opts ...Option) ([]FileResult, error) {
+	c, err := New(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.ProcessFS(ctx, fsys)
+}
+
+// ProcessFS converts every Go file in fsys and returns the results of the files that
+// change, in lexical order. Directories the go command ignores are skipped: vendor,
+// testdata and names starting with "." or "_". If fsys is a WriteFS the converted
+// files are written back to it, keeping their permissions, unless the Converter is a
+// dry run; otherwise nothing is written. Files are converted by up to the configured
+// number of workers at once. A file that fails to convert does not stop the others;
+// its error is returned together with their results. Files of the overlay set with
+// WithOverlay are read from it and never written.
+func (c *Converter) ProcessFS(ctx context.Context, fsys fs.FS) ([]FileResult, error) {
+	var names []string
+
+	err := fs.WalkDir(fsys, ".", func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if isCancelled(ctx) {
+			return fmt.Errorf("context error: %w", ctx.Err())
+		}
+
+		if entry.IsDir() {
+			if name != "." && ignoredDir(entry.Name()) {
+				return fs.SkipDir
+			}
+
+			return nil
+		}
+
+		if path.Ext(name) == ".go" && entry.Type().IsRegular() {
+			names = append(names, name)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("walk: %w", err)
+	}
+
+	for name := range c.overlay {
+		if path.Ext(name) == ".go" && !slices.Contains(names, name) && !inIgnoredDir(name) {
+			names = append(names, name)
+		}
+	}
+
+	slices.Sort(names)
+
+	results := make([]FileResult, len(names))
+	errs := make([]error, len(names))
+
+	var wg sync.WaitGroup
+
+	sem := make(chan struct{}, c.workers)
+
+	for i, name := range names {
+		if isCancelled(ctx) {
+			errs[i] = fmt.Errorf("context error: %w", ctx.Err())
+
+			break
+		}
+
+		sem <- struct{}{}
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			result, err := c.processFSFile(ctx, fsys, name)
+			if errors.Is(err, ErrFileIgnored) {
+				return
+			}
+
+			if err != nil {
+				errs[i] = fmt.Errorf("%s: %w", name, err)
+
+				return
+			}
+
+			results[i] = FileResult{Path: name, Result: result}
+		}()
+	}
+
+	wg.Wait()
+
+	changed := slices.DeleteFunc(results, func(r FileResult) bool {
+		return len(r.Changes) == 0
+	})
+
+	return changed, errors.Join(errs...)
+}
+
+// processFSFile converts the file name of fsys and writes it back if fsys is a WriteFS.
+func (c *Converter) processFSFile(ctx context.Context, fsys fs.FS, name string) (Result, error) {
+	src, overlaid := c.overlay[name]
+	if !overlaid {
+		var err error
+		if src, err = fs.ReadFile(fsys, name); err != nil {
+			return Result{Changes: nil, Source: nil, Suppressed: nil}, fmt.Errorf("read file: %w", err)
+		}
+	}
+
+	result, err := c.Convert(ctx, name, src)
+	if err != nil || len(result.Changes) == 0 {
+		return result, err
+	}
+
+	wfs, ok := fsys.(WriteFS)
+	if !ok || c.dryRun || overlaid {
+		return result, nil
+	}
+
+	info, err := fs.Stat(fsys, name)
+	if err != nil {
+		return result, fmt.Errorf("stat file: %w", err)
+	}
+
+	if err := wfs.WriteFile(name, result.Source, info.Mode().Perm()); err != nil {
+		return result, fmt.Errorf("write file: %w", err)
+	}
+
+	return result, nil
+}
+
+// inIgnoredDir reports whether any directory of the slash-separated name is ignored.
+func inIgnoredDir(name string) bool {
+	for dir := path.Dir(name); dir != "."; dir = path.Dir(dir) {
+		if ignoredDir(path.Base(dir)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ignoredDir reports whether the go command ignores directories named name.
+func ignoredDir(name string) bool {
+	return name == "vendor" || name == "testdata" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")
+}
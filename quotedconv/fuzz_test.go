@@ -0,0 +1,51 @@
+package quotedconv_test
+
+import (
+	"go/parser"
+	"go/token"
+	"os"
+	"testing"
+
+	"github.com/otakakot/quotedconv/quotedconv"
+)
+
+func FuzzConvert(f *testing.F) {
+	if sample, err := os.ReadFile("../sample/main.go"); err == nil {
+		f.Add(sample)
+	}
+
+	for _, seed := range []string{
+		"package x\n\nvar s = `hello`\n",
+		"package x\n\nvar s = `say \"hi\"`\n",
+		"package x\n\nvar s = `C:\\path`\n",
+		"package x\n\nvar s = `line\nbreak`\n",
+		"package x\n\nvar s = `tab\there`\n",
+		"package x\n\ntype T struct {\n\tF int \"json:\\\"f\\\"\"\n}\n",
+		"package x\n\n// #cgo CFLAGS: -DX=`y`\nimport `C`\n\nvar s = `z`\n",
+		"package x\n\n// doc\nconst (\n\tA = `a` // a\n\tBB = `b`\n)\n",
+		"package x\n\nvar s = `\u202e`\n",
+		"package x\n\n//quotedconv:ignore\nvar s = `kept`\n",
+	} {
+		f.Add([]byte(seed))
+	}
+
+	f.Fuzz(func(t *testing.T, src []byte) {
+		if _, err := parser.ParseFile(token.NewFileSet(), "", src, parser.ParseComments); err != nil {
+			t.Skip()
+		}
+
+		out, changed, err := quotedconv.Process(src)
+		if err != nil || !changed {
+			// The file is left untouched.
+			return
+		}
+
+		if _, err := parser.ParseFile(token.NewFileSet(), "", out, parser.ParseComments); err != nil {
+			t.Fatalf("output does not parse: %v\ninput:\n%s\noutput:\n%s", err, src, out)
+		}
+
+		if err := quotedconv.VerifyRoundTrip(out); err != nil {
+			t.Fatalf("VerifyRoundTrip of the output: %v\ninput:\n%s\noutput:\n%s", err, src, out)
+		}
+	})
+}
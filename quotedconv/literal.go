@@ -0,0 +1,289 @@
+package quotedconv
+
+import (
+	"bytes"
+	"fmt"
+	"go/token"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Rules name the conversions recorded in Change.Rule.
+const (
+	RuleRawToInterpreted = "raw-to-interpreted"
+	RuleInterpretedToRaw = "interpreted-to-raw"
+	RuleNormalizeEscapes = "normalize-escapes"
+)
+
+// Change describes a single literal rewrite together with the rule that triggered it.
+type Change struct {
+	// Pos and End are the positions of the first byte of the original literal and of
+	// the byte just after it, in the original source. Their offsets delimit the bytes
+	// replaced by After.
+	Pos token.Position
+	End token.Position
+	// Before is the original literal and After the literal replacing it, as source text.
+	Before string
+	After  string
+	Rule   string
+	Detail string
+	// Secret names the credential pattern the literal matches, if any.
+	Secret string
+	// Rewrites lists the rewrite rules that changed the literal's content.
+	Rewrites []string
+}
+
+func (c Change) String() string {
+	return fmt.Sprintf("%s: %s: %s", c.Pos, c.Rule, c.Detail)
+}
+
+func newChange(pos, end token.Position, before, after string) Change {
+	if !isRawLiteral(before) && !isRawLiteral(after) {
+		return Change{
+			Pos:      pos,
+			End:      end,
+			Before:   before,
+			After:    after,
+			Rule:     RuleNormalizeEscapes,
+			Detail:   fmt.Sprintf("%d escapes before, %d after", countEscapes(before), countEscapes(after)),
+			Secret:   "",
+			Rewrites: nil,
+		}
+	}
+
+	if isRawLiteral(before) {
+		return Change{
+			Pos:      pos,
+			End:      end,
+			Before:   before,
+			After:    after,
+			Rule:     RuleRawToInterpreted,
+			Detail:   fmt.Sprintf("%d escapes added", countEscapes(after)),
+			Secret:   "",
+			Rewrites: nil,
+		}
+	}
+
+	return Change{
+		Pos:      pos,
+		End:      end,
+		Before:   before,
+		After:    after,
+		Rule:     RuleInterpretedToRaw,
+		Detail:   fmt.Sprintf("%d escapes removed", countEscapes(before)),
+		Secret:   "",
+		Rewrites: nil,
+	}
+}
+
+// countEscapes returns the number of escape sequences in an interpreted string literal.
+func countEscapes(value string) int {
+	count := 0
+
+	for i := 0; i < len(value); i++ {
+		if value[i] == '\\' {
+			count++
+			i++
+		}
+	}
+
+	return count
+}
+
+// ConvertLiteral converts value, the source text of a string literal, according to
+// opts, and reports whether it is converted. It applies the conversion rules and the
+// content heuristics, but not the directives and other rules that depend on where the
+// literal appears.
+func ConvertLiteral(value string, opts Options) (string, bool) {
+	converted, ok := convertForm(value, opts)
+	if !ok && opts.NormalizeEscapes && !isRawLiteral(value) {
+		return normalizeEscapes(value, opts)
+	}
+
+	return converted, ok
+}
+
+// normalizeEscapes re-quotes value, an interpreted string literal, according to
+// opts.Escaping, unifying escapes such as \x41, \101 and A.
+func normalizeEscapes(value string, opts Options) (string, bool) {
+	content, err := strconv.Unquote(value)
+	if err != nil {
+		return "", false
+	}
+
+	quoted := opts.Escaping.quote(content)
+	if quoted == value {
+		return "", false
+	}
+
+	return quoted, true
+}
+
+// convertForm converts value between the raw and interpreted forms as selected by opts.
+func convertForm(value string, opts Options) (string, bool) {
+	if isRawLiteral(value) && keepRaw(value[1:len(value)-1], opts) {
+		return "", false
+	}
+
+	if opts.ToRaw {
+		return rawForm(value)
+	}
+
+	if opts.Canonical {
+		return canonicalForm(value, opts)
+	}
+
+	if isRawLiteral(value) {
+		if !shouldConvertLiteral(value, opts) {
+			return "", false
+		}
+
+		// Unquote rather than slice: raw literals drop carriage returns from their value.
+		content, err := strconv.Unquote(value)
+		if err != nil {
+			return "", false
+		}
+
+		return opts.Escaping.quote(content), true
+	}
+
+	if opts.QuotePolicy == QuotePolicyRaw && strings.Contains(value, `\"`) {
+		content, err := strconv.Unquote(value)
+		if err != nil || !canBeRaw(content) {
+			return "", false
+		}
+
+		return "`" + content + "`", true
+	}
+
+	return "", false
+}
+
+// rawLiteralHasCR reports whether the raw literal starting at offset contains carriage
+// returns in the source. The scanner strips them from ast.BasicLit.Value, so the
+// original bytes have to be consulted.
+func rawLiteralHasCR(src []byte, offset int) bool {
+	if offset < 0 || offset >= len(src) {
+		return false
+	}
+
+	end := bytes.IndexByte(src[offset+1:], '`')
+	if end < 0 {
+		return false
+	}
+
+	return bytes.IndexByte(src[offset+1:offset+1+end], '\r') >= 0
+}
+
+// literalEnd returns the offset just after the string literal value starting at offset
+// in src. The scanner strips carriage returns from raw literals, so their end has to be
+// found in the source.
+func literalEnd(src []byte, offset int, value string) int {
+	if !isRawLiteral(value) || offset < 0 || offset >= len(src) {
+		return offset + len(value)
+	}
+
+	if closing := bytes.IndexByte(src[offset+1:], '`'); closing >= 0 {
+		return offset + 1 + closing + 1
+	}
+
+	return offset + len(value)
+}
+
+func isRawLiteral(value string) bool {
+	return len(value) >= 2 && strings.HasPrefix(value, "`") && strings.HasSuffix(value, "`")
+}
+
+func shouldConvertLiteral(value string, opts Options) bool {
+	if !isRawLiteral(value) {
+		return false
+	}
+
+	content := value[1 : len(value)-1]
+	if strings.Contains(content, "\"") && opts.QuotePolicy != QuotePolicyEscape {
+		return false
+	}
+
+	if strings.Contains(content, "\\") && !opts.EscapeBackslashes {
+		return false
+	}
+
+	return !strings.ContainsAny(content, "\n`")
+}
+
+func canBeRaw(content string) bool {
+	if !utf8.ValidString(content) || strings.ContainsRune(content, '\uFEFF') {
+		return false
+	}
+
+	for _, r := range content {
+		if r == '`' || (unicode.IsControl(r) && r != '\t') {
+			return false
+		}
+	}
+
+	return true
+}
+
+// rawForm returns value, an interpreted string literal, as a raw string literal if it
+// has at least one escape sequence and all of them are \" or \\, so the raw form is
+// strictly shorter and reads the same.
+func rawForm(value string) (string, bool) {
+	if isRawLiteral(value) || !strings.Contains(value, `\`) {
+		return "", false
+	}
+
+	for i := 1; i < len(value)-1; i++ {
+		if value[i] != '\\' {
+			continue
+		}
+
+		if i++; value[i] != '"' && value[i] != '\\' {
+			return "", false
+		}
+	}
+
+	content, err := strconv.Unquote(value)
+	if err != nil || !canBeRaw(content) {
+		return "", false
+	}
+
+	return "`" + content + "`", true
+}
+
+// tagRawForm returns value, an interpreted struct tag, in the conventional raw form.
+func tagRawForm(value string) (string, bool) {
+	if isRawLiteral(value) {
+		return "", false
+	}
+
+	content, err := strconv.Unquote(value)
+	if err != nil || !canBeRaw(content) {
+		return "", false
+	}
+
+	return "`" + content + "`", true
+}
+
+// canonicalForm returns value in whichever form needs fewer escape sequences: a raw
+// literal is converted if its interpreted form needs none, and an interpreted literal if
+// its raw form exists. Both rules also pick the shorter form.
+func canonicalForm(value string, opts Options) (string, bool) {
+	if !isRawLiteral(value) {
+		return rawForm(value)
+	}
+
+	content, err := strconv.Unquote(value)
+	if err != nil {
+		return "", false
+	}
+
+	quoted := opts.Escaping.quote(content)
+	if countEscapes(quoted) > 0 {
+		return "", false
+	}
+
+	return quoted, true
+}
@@ -0,0 +1,158 @@
+package quotedconv_test
+
+import (
+	"testing"
+
+	"github.com/otakakot/quotedconv/quotedconv"
+)
+
+// literalTest is a case for quotedconv.ConvertLiteral: want is the converted literal,
+// or "" if value is left alone.
+type literalTest struct {
+	value string
+	want  string
+}
+
+func runLiteralTests(t *testing.T, opts quotedconv.Options, tests []literalTest) {
+	t.Helper()
+
+	for _, tt := range tests {
+		got, ok := quotedconv.ConvertLiteral(tt.value, opts)
+		if !ok {
+			got = ""
+		}
+
+		if got != tt.want {
+			t.Errorf("ConvertLiteral(%s) = %s, want %s", tt.value, orUnchanged(got), orUnchanged(tt.want))
+		}
+	}
+}
+
+func orUnchanged(s string) string {
+	if s == "" {
+		return "unchanged"
+	}
+
+	return s
+}
+
+func TestConvertLiteralToRaw(t *testing.T) {
+	opts := quotedconv.DefaultOptions()
+	opts.ToRaw = true
+
+	runLiteralTests(t, opts, []literalTest{
+		{value: `"say \"hi\""`, want: "`say \"hi\"`"},
+		{value: `"C:\\dir"`, want: "`C:\\dir`"},
+		{value: `"\\\""`, want: "`\\\"`"},
+		{value: `"plain"`, want: ""},
+		{value: `"tab\t"`, want: ""},
+		{value: `"\"quoted\"\n"`, want: ""},
+		{value: `"\x41\\"`, want: ""},
+		{value: `"back` + "\\u0060" + `tick\\"`, want: ""},
+		{value: "`already raw`", want: ""},
+	})
+}
+
+func TestConvertLiteralCanonical(t *testing.T) {
+	opts := quotedconv.DefaultOptions()
+	opts.Canonical = true
+
+	runLiteralTests(t, opts, []literalTest{
+		// No escapes either way: the tie goes to the interpreted form.
+		{value: "`plain`", want: `"plain"`},
+		{value: `"plain"`, want: ""},
+		{value: "`say \"hi\"`", want: ""},
+		{value: `"say \"hi\""`, want: "`say \"hi\"`"},
+		{value: "`C:\\dir`", want: ""},
+		{value: `"C:\\dir"`, want: "`C:\\dir`"},
+		{value: "`tab\there`", want: ""},
+		{value: `"tab\there"`, want: ""},
+		{value: "`é`", want: `"é"`},
+	})
+}
+
+func TestConvertLiteralEscapeBackslashes(t *testing.T) {
+	opts := quotedconv.DefaultOptions()
+
+	runLiteralTests(t, opts, []literalTest{
+		{value: "`C:\\temp`", want: ""},
+	})
+
+	opts.EscapeBackslashes = true
+
+	runLiteralTests(t, opts, []literalTest{
+		{value: "`C:\\temp`", want: `"C:\\temp"`},
+		{value: "`\\d+`", want: `"\\d+"`},
+		{value: "`\\`", want: `"\\"`},
+		// Quotes still follow the quote policy.
+		{value: "`a\\\"b`", want: ""},
+	})
+
+	opts.QuotePolicy = quotedconv.QuotePolicyEscape
+
+	runLiteralTests(t, opts, []literalTest{
+		{value: "`a\\\"b`", want: `"a\\\"b"`},
+	})
+}
+
+func TestConvertLiteralEscapingASCII(t *testing.T) {
+	opts := quotedconv.DefaultOptions()
+	opts.Escaping = quotedconv.EscapingASCII
+
+	runLiteralTests(t, opts, []literalTest{
+		{value: "`plain`", want: `"plain"`},
+		{value: "`é`", want: `"\u00e9"`},
+		{value: "`日本`", want: `"\u65e5\u672c"`},
+		{value: "`😀`", want: `"\U0001f600"`},
+		{value: "`tab\t`", want: `"tab\t"`},
+	})
+}
+
+func TestConvertLiteralEscapingUnicodeAndGraphic(t *testing.T) {
+	opts := quotedconv.DefaultOptions()
+
+	// U+00A0 and U+3000 are graphic but not printable; U+200B is neither.
+	runLiteralTests(t, opts, []literalTest{
+		{value: "`\u00e9`", want: "\"\u00e9\""},
+		{value: "`a\u00a0b`", want: `"a\u00a0b"`},
+		{value: "`a\u3000b`", want: `"a\u3000b"`},
+		{value: "`a\u200bb`", want: `"a\u200bb"`},
+	})
+
+	opts.Escaping = quotedconv.EscapingGraphic
+
+	runLiteralTests(t, opts, []literalTest{
+		{value: "`\u00e9`", want: "\"\u00e9\""},
+		{value: "`a\u00a0b`", want: "\"a\u00a0b\""},
+		{value: "`a\u3000b`", want: "\"a\u3000b\""},
+		{value: "`a\u200bb`", want: `"a\u200bb"`},
+	})
+}
+
+func TestConvertLiteralNormalizeEscapes(t *testing.T) {
+	opts := quotedconv.DefaultOptions()
+
+	runLiteralTests(t, opts, []literalTest{
+		{value: `"\x41"`, want: ""},
+	})
+
+	opts.NormalizeEscapes = true
+
+	runLiteralTests(t, opts, []literalTest{
+		{value: `"\x41"`, want: `"A"`},
+		{value: `"\101"`, want: `"A"`},
+		{value: `"\u0041"`, want: `"A"`},
+		{value: `"\u00e9"`, want: "\"\u00e9\""},
+		{value: `"\x09"`, want: `"\t"`},
+		{value: `"plain"`, want: ""},
+		{value: `"\t\n"`, want: ""},
+		{value: "`raw`", want: `"raw"`},
+	})
+
+	opts.Escaping = quotedconv.EscapingASCII
+
+	runLiteralTests(t, opts, []literalTest{
+		{value: "\"\u00e9\"", want: `"\u00e9"`},
+		{value: `"\u00e9"`, want: ""},
+	})
+}
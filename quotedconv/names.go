@@ -0,0 +1,57 @@
+package quotedconv
+
+import (
+	"go/ast"
+	"go/token"
+)
+
+// assignedNames maps the positions of string literals to the name of the constant or
+// variable whose value they are part of, in declarations and assignments alike. A
+// literal inside nested assignments, such as in a function literal, gets the innermost
+// name.
+func assignedNames(file *ast.File) map[token.Pos]string {
+	names := map[token.Pos]string{}
+
+	mark := func(name *ast.Ident, value ast.Expr) {
+		ast.Inspect(value, func(n ast.Node) bool {
+			if lit, ok := n.(*ast.BasicLit); ok && lit.Kind == token.STRING {
+				names[lit.Pos()] = name.Name
+			}
+
+			return true
+		})
+	}
+
+	ast.Inspect(file, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.ValueSpec:
+			if len(n.Names) == len(n.Values) {
+				for i, name := range n.Names {
+					mark(name, n.Values[i])
+				}
+			}
+		case *ast.AssignStmt:
+			if len(n.Lhs) == len(n.Rhs) {
+				for i, lhs := range n.Lhs {
+					if name, ok := lhs.(*ast.Ident); ok {
+						mark(name, n.Rhs[i])
+					}
+				}
+			}
+		}
+
+		return true
+	})
+
+	return names
+}
+
+// skipName reports whether a literal assigned to name, "" if none, is excluded by
+// opts.NamePattern or opts.SkipNamePattern.
+func (c *Converter) skipName(name string) bool {
+	if c.namePattern != nil && !c.namePattern.MatchString(name) {
+		return true
+	}
+
+	return c.skipNamePattern != nil && name != "" && c.skipNamePattern.MatchString(name)
+}
@@ -0,0 +1,40 @@
+package quotedconv_test
+
+import (
+	"testing"
+
+	"github.com/otakakot/quotedconv/quotedconv"
+)
+
+func TestConvertNamePatterns(t *testing.T) {
+	const src = "const msgHello = `hello`\n" +
+		"\n" +
+		"const userQuery = `q`\n" +
+		"\n" +
+		"var msgA, msgB = `a`, `b`\n" +
+		"\n" +
+		"var msgFn = func() string {\n" +
+		"\tinner := `i`\n" +
+		"\tg(`outer`)\n" +
+		"\treturn inner\n" +
+		"}\n" +
+		"\n" +
+		"func g(string) {}\n" +
+		"\n" +
+		"func h() { g(`unnamed`) }\n"
+
+	opts := quotedconv.DefaultOptions()
+	opts.NamePattern = "^msg"
+
+	runConversionTests(t, opts, []conversionTest{
+		// Literals get the innermost name they are assigned to, if any.
+		{name: "name pattern", src: src, want: []string{"`hello`", "`a`", "`b`", "`outer`"}},
+	})
+
+	opts = quotedconv.DefaultOptions()
+	opts.SkipNamePattern = "Query$"
+
+	runConversionTests(t, opts, []conversionTest{
+		{name: "skip name pattern", src: src, want: []string{"`hello`", "`a`", "`b`", "`i`", "`outer`", "`unnamed`"}},
+	})
+}
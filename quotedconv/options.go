@@ -0,0 +1,384 @@
+package quotedconv
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// QuotePolicy decides what happens to literals whose content contains double quotes.
+type QuotePolicy string
+
+const (
+	// QuotePolicySkip leaves raw literals containing double quotes untouched.
+	QuotePolicySkip QuotePolicy = "skip"
+	// QuotePolicyEscape converts raw literals containing double quotes, escaping the quotes.
+	QuotePolicyEscape QuotePolicy = "escape"
+	// QuotePolicyRaw keeps raw literals containing double quotes and converts interpreted
+	// literals with escaped quotes to raw literals when their value allows it.
+	QuotePolicyRaw QuotePolicy = "raw"
+)
+
+func (p *QuotePolicy) String() string {
+	return string(*p)
+}
+
+func (p *QuotePolicy) Set(value string) error {
+	switch QuotePolicy(value) {
+	case QuotePolicySkip, QuotePolicyEscape, QuotePolicyRaw:
+		*p = QuotePolicy(value)
+
+		return nil
+	default:
+		return fmt.Errorf("unknown quote policy %q", value)
+	}
+}
+
+// ScopeContext is a syntactic context a literal appears in.
+type ScopeContext string
+
+const (
+	// ScopeConst covers literals inside const declarations.
+	ScopeConst ScopeContext = "const"
+	// ScopeVar covers literals inside var declarations.
+	ScopeVar ScopeContext = "var"
+	// ScopeComposite covers elements of composite literals.
+	ScopeComposite ScopeContext = "composite"
+	// ScopeCallArg covers arguments of function calls and conversions.
+	ScopeCallArg ScopeContext = "callarg"
+	// ScopeReturn covers results of return statements.
+	ScopeReturn ScopeContext = "return"
+)
+
+// scopeAll is the flag value converting literals wherever they appear.
+const scopeAll = "all"
+
+// Scope restricts conversion to literals in any of its contexts. An empty Scope
+// converts literals wherever they appear.
+type Scope []ScopeContext
+
+func (s *Scope) String() string {
+	if s == nil || len(*s) == 0 {
+		return scopeAll
+	}
+
+	names := make([]string, len(*s))
+	for i, context := range *s {
+		names[i] = string(context)
+	}
+
+	return strings.Join(names, ",")
+}
+
+func (s *Scope) Set(value string) error {
+	var scope Scope
+
+	for _, name := range strings.Split(value, ",") {
+		switch context := ScopeContext(strings.TrimSpace(name)); context {
+		case scopeAll:
+			// An empty scope covers every literal.
+			*s = nil
+
+			return nil
+		case ScopeConst, ScopeVar, ScopeComposite, ScopeCallArg, ScopeReturn:
+			scope = append(scope, context)
+		default:
+			return fmt.Errorf("unknown scope %q", name)
+		}
+	}
+
+	*s = scope
+
+	return nil
+}
+
+// CharPolicy decides what happens to raw literals containing a class of characters
+// that are hard to see.
+type CharPolicy string
+
+const (
+	// CharPolicyEscape converts the literal, escaping the characters.
+	CharPolicyEscape CharPolicy = "escape"
+	// CharPolicySkip leaves the literal untouched and warns.
+	CharPolicySkip CharPolicy = "skip"
+	// CharPolicyError fails the file.
+	CharPolicyError CharPolicy = "error"
+)
+
+func (p *CharPolicy) String() string {
+	return string(*p)
+}
+
+func (p *CharPolicy) Set(value string) error {
+	switch CharPolicy(value) {
+	case CharPolicyEscape, CharPolicySkip, CharPolicyError:
+		*p = CharPolicy(value)
+
+		return nil
+	default:
+		return fmt.Errorf("unknown character policy %q", value)
+	}
+}
+
+// Escaping decides how runes are represented in converted interpreted literals.
+type Escaping string
+
+const (
+	// EscapingUnicode keeps printable runes as they are and escapes the rest, like
+	// strconv.Quote.
+	EscapingUnicode Escaping = "unicode"
+	// EscapingASCII escapes every non-ASCII rune, like strconv.QuoteToASCII.
+	EscapingASCII Escaping = "ascii"
+	// EscapingGraphic keeps graphic runes, including spaces other than U+0020, as they
+	// are and escapes the rest, like strconv.QuoteToGraphic.
+	EscapingGraphic Escaping = "graphic"
+)
+
+func (e *Escaping) String() string {
+	return string(*e)
+}
+
+func (e *Escaping) Set(value string) error {
+	switch Escaping(value) {
+	case EscapingUnicode, EscapingASCII, EscapingGraphic:
+		*e = Escaping(value)
+
+		return nil
+	default:
+		return fmt.Errorf("unknown escaping %q", value)
+	}
+}
+
+// quote returns content as an interpreted string literal escaped according to e.
+func (e Escaping) quote(content string) string {
+	switch e {
+	case EscapingASCII:
+		return strconv.QuoteToASCII(content)
+	case EscapingGraphic:
+		return strconv.QuoteToGraphic(content)
+	default:
+		return strconv.Quote(content)
+	}
+}
+
+// LineRange restricts conversion to literals within an inclusive range of lines. A
+// zero Start or End leaves that side of the range open, so the zero value covers
+// every line.
+type LineRange struct {
+	Start int
+	End   int
+}
+
+func (r *LineRange) String() string {
+	if r == nil || *r == (LineRange{}) {
+		return ""
+	}
+
+	return fmt.Sprintf("%d:%d", r.Start, r.End)
+}
+
+func (r *LineRange) Set(value string) error {
+	start, end, ok := strings.Cut(value, ":")
+	if !ok {
+		return fmt.Errorf("invalid line range %q, want START:END", value)
+	}
+
+	var parsed LineRange
+
+	for _, bound := range []struct {
+		text string
+		n    *int
+	}{{start, &parsed.Start}, {end, &parsed.End}} {
+		if bound.text == "" {
+			continue
+		}
+
+		n, err := strconv.Atoi(bound.text)
+		if err != nil || n < 1 {
+			return fmt.Errorf("invalid line range %q, want START:END", value)
+		}
+
+		*bound.n = n
+	}
+
+	if parsed.End > 0 && parsed.Start > parsed.End {
+		return fmt.Errorf("invalid line range %q: start after end", value)
+	}
+
+	*r = parsed
+
+	return nil
+}
+
+// contains reports whether the lines first through last lie within the range.
+func (r LineRange) contains(first, last int) bool {
+	return first >= r.Start && (r.End == 0 || last <= r.End)
+}
+
+// Tracer starts a span for a phase of a conversion, such as "parse", "rewrite" or
+// "format", and returns the function that ends it with the error the phase failed
+// with, if any.
+type Tracer func(ctx context.Context, name string) (end func(err error))
+
+// Heuristics leave literals untouched that the conversion rules allow but that are
+// likely raw on purpose.
+type Heuristics struct {
+	// PatternCalls leaves string literals passed to regexp and template constructors
+	// untouched; they are usually raw on purpose.
+	PatternCalls bool
+	// KeepSQL and KeepJSON leave raw literals whose content looks like an SQL statement
+	// or a JSON document untouched.
+	KeepSQL  bool
+	KeepJSON bool
+	// KeepPaths leaves raw literals whose content looks like a URL or a filesystem path
+	// untouched.
+	KeepPaths bool
+	// MinLen and MaxLen restrict conversion to raw literals whose content length in
+	// characters is within a range; zero disables the bound.
+	MinLen int
+	MaxLen int
+	// ReadabilityCap keeps single-line raw literals longer than this many characters raw
+	// if their conversion would add escapes, whatever the other options; zero disables
+	// it.
+	ReadabilityCap int
+}
+
+// DefaultHeuristics returns the heuristics of DefaultOptions.
+func DefaultHeuristics() Heuristics {
+	return Heuristics{
+		PatternCalls:   true,
+		KeepSQL:        false,
+		KeepJSON:       false,
+		KeepPaths:      false,
+		MinLen:         0,
+		MaxLen:         0,
+		ReadabilityCap: 0,
+	}
+}
+
+// Options controls which literals are converted and how.
+type Options struct {
+	QuotePolicy QuotePolicy
+	// EscapeBackslashes converts raw literals containing backslashes, escaping them.
+	EscapeBackslashes bool
+	// Escaping decides how runes are represented in converted literals.
+	Escaping Escaping
+	// NormalizeEscapes re-quotes existing interpreted literals according to Escaping.
+	NormalizeEscapes bool
+	// Invisible decides what happens to raw literals containing invisible format
+	// characters, such as bidirectional controls and zero-width runes.
+	Invisible CharPolicy
+	// ControlChars decides what happens to raw literals containing control characters,
+	// such as tabs and carriage returns.
+	ControlChars CharPolicy
+	// ToRaw reverses the conversion: interpreted literals whose only escapes are \" and
+	// \\ become raw literals, and raw literals are left alone.
+	ToRaw bool
+	// Canonical converts every literal to whichever form needs fewer escapes, preferring
+	// interpreted literals on a tie.
+	Canonical bool
+	// RawTags rewrites interpreted struct tags to raw literals; otherwise struct tags are
+	// never touched.
+	RawTags bool
+	// Scope restricts conversion to literals in certain syntactic contexts.
+	Scope Scope
+	// Lines restricts conversion to literals within a range of lines.
+	Lines LineRange
+	// Verbose logs the literals kept raw by ReadabilityCap.
+	Verbose bool
+	// RequireEnable only converts files carrying a //quotedconv:enable directive.
+	RequireEnable bool
+	Heuristics
+	// SkipCalls names functions, as package.Function, whose string literal arguments are
+	// never converted. The package is an import path or the name a file refers to it by.
+	SkipCalls []string
+	// NamePattern restricts conversion to literals assigned to constants or variables
+	// whose name matches it; SkipNamePattern excludes those. Empty patterns are unset.
+	NamePattern     string
+	SkipNamePattern string
+	// Rewrites are applied to the content of every converted literal.
+	Rewrites []RewriteRule
+	// ReportSuppressed records the convertible literals left alone by heuristics or
+	// directives in Result.Suppressed.
+	ReportSuppressed bool
+	// Trace, if set, is called for every phase of a conversion.
+	Trace Tracer `json:"-"`
+}
+
+// DefaultOptions returns the options the quotedconv command runs with when no flags
+// are given.
+func DefaultOptions() Options {
+	return Options{
+		QuotePolicy:       QuotePolicySkip,
+		EscapeBackslashes: false,
+		Escaping:          EscapingUnicode,
+		NormalizeEscapes:  false,
+		Invisible:         CharPolicyEscape,
+		ControlChars:      CharPolicyEscape,
+		ToRaw:             false,
+		Canonical:         false,
+		RawTags:           false,
+		Scope:             nil,
+		Lines:             LineRange{Start: 0, End: 0},
+		Verbose:           false,
+		RequireEnable:     false,
+		Heuristics:        DefaultHeuristics(),
+		SkipCalls:         nil,
+		NamePattern:       "",
+		SkipNamePattern:   "",
+		Rewrites:          nil,
+		ReportSuppressed:  false,
+		Trace:             nil,
+	}
+}
+
+func (o Options) validate() error {
+	// Options may be decoded from configuration rather than set by flags, so the enum
+	// values are checked the way their flags would check them.
+	for _, value := range []interface {
+		String() string
+		Set(value string) error
+	}{&o.QuotePolicy, &o.Escaping, &o.Invisible, &o.ControlChars, &o.Scope} {
+		if err := value.Set(value.String()); err != nil {
+			return err
+		}
+	}
+
+	if o.ToRaw && o.QuotePolicy != QuotePolicySkip {
+		return fmt.Errorf("to-raw cannot be combined with quote policy %q", o.QuotePolicy)
+	}
+
+	if o.EscapeBackslashes && (o.ToRaw || o.Canonical) {
+		return errors.New("escape-backslashes cannot be combined with to-raw or canonical")
+	}
+
+	if o.Canonical && o.ToRaw {
+		return errors.New("canonical and to-raw are mutually exclusive")
+	}
+
+	if o.Canonical && o.QuotePolicy != QuotePolicySkip {
+		return fmt.Errorf("canonical cannot be combined with quote policy %q", o.QuotePolicy)
+	}
+
+	for _, call := range o.SkipCalls {
+		if dot := strings.LastIndex(call, "."); dot <= 0 || dot == len(call)-1 {
+			return fmt.Errorf("invalid skip call %q, want package.Function", call)
+		}
+	}
+
+	if o.MinLen < 0 || o.MaxLen < 0 {
+		return fmt.Errorf("literal length bounds must not be negative, got %d and %d", o.MinLen, o.MaxLen)
+	}
+
+	if o.ReadabilityCap < 0 {
+		return fmt.Errorf("readability cap must not be negative, got %d", o.ReadabilityCap)
+	}
+
+	if o.MaxLen > 0 && o.MinLen > o.MaxLen {
+		return fmt.Errorf("minimum length %d exceeds maximum length %d", o.MinLen, o.MaxLen)
+	}
+
+	return nil
+}
@@ -0,0 +1,529 @@
+// Package quotedconv converts raw string literals (backtick-quoted strings) in Go source
+// to interpreted string literals (double-quoted strings) where they read as well, and
+// can convert in the opposite direction. It is the engine of the quotedconv command and
+// lets code generators and formatters apply the same conversion without running it.
+package quotedconv
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"io"
+	"io/fs"
+	"log"
+	"regexp"
+	"runtime"
+	"slices"
+)
+
+// Process converts the string literals of src, a Go source file, with the default
+// options. It returns src itself and changed false if no literal is converted or the
+// file carries a //quotedconv:file-ignore directive.
+func Process(src []byte) (out []byte, changed bool, err error) {
+	c, err := NewConverter(DefaultOptions())
+	if err != nil {
+		return nil, false, err
+	}
+
+	result, err := c.Convert(context.Background(), "", src)
+	if err != nil && !errors.Is(err, ErrFileIgnored) {
+		return nil, false, err
+	}
+
+	if len(result.Changes) == 0 {
+		return src, false, nil
+	}
+
+	return result.Source, true, nil
+}
+
+// Converter converts source files according to a fixed set of options. Everything
+// derived from the options is prepared once by NewConverter and never mutated
+// afterwards, so a single Converter may be shared by any number of goroutines.
+type Converter struct {
+	opts    Options
+	workers int
+	dryRun  bool
+	logger  Logger
+	// onConvert, if set, is asked to approve every change.
+	onConvert func(Change) bool
+	// overlay replaces the contents of files read by ProcessFS.
+	overlay map[string][]byte
+
+	rewrites []compiledRewrite
+	// skipCalls is the set of opts.SkipCalls.
+	skipCalls map[string]bool
+	// namePattern and skipNamePattern are the compiled opts.NamePattern and
+	// opts.SkipNamePattern; nil if unset.
+	namePattern     *regexp.Regexp
+	skipNamePattern *regexp.Regexp
+}
+
+// NewConverter returns a Converter for opts. It is New(WithOptions(opts)).
+func NewConverter(opts Options) (*Converter, error) {
+	return New(WithOptions(opts))
+}
+
+// New returns a Converter starting from the default options, adjusted by opts in
+// order.
+func New(opts ...Option) (*Converter, error) {
+	c := &Converter{opts: DefaultOptions(), workers: runtime.NumCPU(), dryRun: false, logger: log.Default(), onConvert: nil, overlay: nil, rewrites: nil, skipCalls: nil, namePattern: nil, skipNamePattern: nil}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	if c.logger == nil {
+		c.logger = log.New(io.Discard, "", 0)
+	}
+
+	if err := c.opts.validate(); err != nil {
+		return nil, fmt.Errorf("invalid options: %w", err)
+	}
+
+	if c.workers < 1 {
+		return nil, fmt.Errorf("invalid options: workers must be positive, got %d", c.workers)
+	}
+
+	for name := range c.overlay {
+		if !fs.ValidPath(name) || name == "." {
+			return nil, fmt.Errorf("invalid options: invalid overlay file name %q", name)
+		}
+	}
+
+	rewrites, err := compileRewrites(c.opts.Rewrites)
+	if err != nil {
+		return nil, fmt.Errorf("invalid options: %w", err)
+	}
+
+	skipCalls := make(map[string]bool, len(c.opts.SkipCalls))
+	for _, call := range c.opts.SkipCalls {
+		skipCalls[call] = true
+	}
+
+	namePattern, err := compileOptional(c.opts.NamePattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid options: name pattern: %w", err)
+	}
+
+	skipNamePattern, err := compileOptional(c.opts.SkipNamePattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid options: skip name pattern: %w", err)
+	}
+
+	c.rewrites, c.skipCalls, c.namePattern, c.skipNamePattern = rewrites, skipCalls, namePattern, skipNamePattern
+
+	return c, nil
+}
+
+// Result is the outcome of converting a source file.
+type Result struct {
+	// Changes lists the converted literals in source order.
+	Changes []Change
+	// Source is the converted source; nil when nothing changes.
+	Source []byte
+	// Suppressed lists the convertible literals left alone by heuristics or directives,
+	// collected if Options.ReportSuppressed is set.
+	Suppressed []Suppression
+}
+
+// Convert converts the literals of src, the content of the Go source file filename.
+// The file name is only used for positions and may be empty. A file carrying a
+// //quotedconv:file-ignore directive fails with ErrFileIgnored.
+func (c *Converter) Convert(ctx context.Context, filename string, src []byte) (Result, error) {
+	result := Result{Changes: nil, Source: nil, Suppressed: nil}
+
+	if hasFileIgnore(src) {
+		return result, ErrFileIgnored
+	}
+
+	// Files not opted in are left alone quietly: in a repository adopting the tool
+	// gradually, they are the majority.
+	if c.opts.RequireEnable && !hasEnable(src) {
+		return result, nil
+	}
+
+	end := c.trace(ctx, "parse")
+	file, fset, err := parseGoFile(filename, src)
+	end(err)
+
+	if err != nil {
+		return result, err
+	}
+
+	ranges := declRanges(fset, file, src)
+
+	end = c.trace(ctx, "rewrite")
+	changes, suppressed, err := c.processAST(ctx, fset, file, src)
+	end(err)
+
+	if err != nil {
+		return result, err
+	}
+
+	if isCancelled(ctx) {
+		return result, fmt.Errorf("context error: %w", ctx.Err())
+	}
+
+	result.Suppressed = suppressed
+
+	if len(changes) == 0 {
+		return result, nil
+	}
+
+	end = c.trace(ctx, "format")
+	formatted, err := formatChangedDecls(fset, file, src, ranges, changes)
+	end(err)
+
+	if err != nil {
+		return result, err
+	}
+
+	result.Changes, result.Source = changes, formatted
+
+	return result, nil
+}
+
+// trace starts a span for the named phase if tracing is enabled.
+func (c *Converter) trace(ctx context.Context, name string) func(error) {
+	if c.opts.Trace == nil {
+		return func(error) {}
+	}
+
+	return c.opts.Trace(ctx, name)
+}
+
+type skipKey struct{}
+
+type skipFilter struct {
+	skip   func(token.Position) bool
+	reason string
+}
+
+// WithSkip returns a context under which Convert leaves alone the literals for whose
+// position skip reports true, as it does for those marked by an ignore directive.
+// reason describes them in Result.Suppressed. It lets callers exclude literals by
+// information Convert does not have, such as their type.
+func WithSkip(ctx context.Context, skip func(pos token.Position) bool, reason string) context.Context {
+	return context.WithValue(ctx, skipKey{}, skipFilter{skip: skip, reason: reason})
+}
+
+func parseGoFile(filename string, src []byte) (*ast.File, *token.FileSet, error) {
+	fset := token.NewFileSet()
+
+	file, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+	if err != nil {
+		return nil, nil, fmt.Errorf("parse file: %w", err)
+	}
+
+	return file, fset, nil
+}
+
+func (c *Converter) processAST(ctx context.Context, fset *token.FileSet, file *ast.File, src []byte) ([]Change, []Suppression, error) {
+	opts := c.opts
+
+	var (
+		changes    []Change
+		suppressed []Suppression
+	)
+
+	tagPositions := make(map[token.Pos]bool)
+
+	ast.Inspect(file, func(n ast.Node) bool {
+		if field, ok := n.(*ast.Field); ok && field.Tag != nil {
+			tagPositions[field.Tag.Pos()] = true
+		}
+
+		return true
+	})
+
+	forced := directiveTargets(fset, file, directiveForce)
+	ignored := directiveTargets(fset, file, directiveIgnore)
+	callArgs := c.callArgTargets(file)
+	inScope := scopeTargets(file, opts.Scope)
+
+	var names map[token.Pos]string
+	if c.namePattern != nil || c.skipNamePattern != nil {
+		names = assignedNames(file)
+	}
+
+	// Line ranges of multi-line literals rewritten to a single line.
+	var collapsed [][2]int
+
+	skip, _ := ctx.Value(skipKey{}).(skipFilter)
+
+	var err error
+
+	ast.Inspect(file, func(n ast.Node) bool {
+		if err != nil || isCancelled(ctx) {
+			return false
+		}
+
+		if decl, ok := n.(*ast.GenDecl); ok && isCgoImport(decl) {
+			return false
+		}
+
+		lit, ok := n.(*ast.BasicLit)
+		if !ok || lit.Kind != token.STRING {
+			return true
+		}
+
+		tag := tagPositions[lit.Pos()]
+
+		if tag && !opts.RawTags && !forced[lit.Pos()] {
+			return true
+		}
+
+		suppress := func(reason string) bool {
+			if opts.ReportSuppressed && convertible(lit.Value, opts) {
+				pos := fset.Position(lit.Pos())
+				c.logger.Printf("Suppressed: %s: %s", pos, reason)
+
+				suppressed = append(suppressed, Suppression{Pos: pos, Reason: reason})
+			}
+
+			return true
+		}
+
+		switch {
+		case ignored[lit.Pos()]:
+			return suppress("ignore directive")
+		case forced[lit.Pos()]:
+		case callArgs[lit.Pos()]:
+			return suppress("argument of a pattern or skipped call")
+		case names != nil && c.skipName(names[lit.Pos()]):
+			return suppress("name pattern")
+		case skip.skip != nil && skip.skip(fset.Position(lit.Pos())):
+			return suppress(skip.reason)
+		}
+
+		if inScope != nil && !inScope[lit.Pos()] {
+			return suppress("out of scope")
+		}
+
+		if !opts.Lines.contains(fset.Position(lit.Pos()).Line, fset.Position(lit.End()).Line) {
+			return true
+		}
+
+		var value string
+		if tag && opts.RawTags {
+			value, ok = tagRawForm(lit.Value)
+		} else {
+			value, ok = ConvertLiteral(lit.Value, opts)
+		}
+
+		if !ok && forced[lit.Pos()] {
+			value, ok = forceConvertLiteral(lit.Value, opts)
+		}
+
+		if !ok && isRawLiteral(lit.Value) && keepRaw(lit.Value[1:len(lit.Value)-1], opts) {
+			return suppress("content or length heuristic")
+		}
+
+		if ok && isRawLiteral(lit.Value) {
+			pos := fset.Position(lit.Pos())
+
+			var skip bool
+			if skip, err = c.checkRunes(pos, lit.Value[1:len(lit.Value)-1], rawLiteralHasCR(src, pos.Offset)); err != nil {
+				return false
+			} else if skip {
+				return suppress("character policy")
+			}
+
+			if reason := readabilityCap(lit.Value, value, opts); reason != "" && !forced[lit.Pos()] {
+				if opts.Verbose {
+					c.logger.Printf("Kept raw: %s: %s", pos, reason)
+				}
+
+				return suppress(reason)
+			}
+		}
+
+		if ok {
+			value, rewrites := applyRewrites(value, c.rewrites, opts)
+
+			pos := fset.Position(lit.Pos())
+			tf := fset.File(lit.Pos())
+			end := tf.PositionFor(tf.Pos(literalEnd(src, pos.Offset, lit.Value)), false)
+
+			change := newChange(pos, end, lit.Value, value)
+			change.Rewrites = rewrites
+
+			if len(rewrites) > 0 {
+				change.Detail += fmt.Sprintf(", %d rewrite rules applied", len(rewrites))
+			}
+
+			if forced[lit.Pos()] {
+				change.Detail += ", forced by directive"
+			}
+
+			if tag {
+				change.Detail += ", struct tag"
+			}
+
+			if change.Secret = detectSecret(change.Before); change.Secret == "" {
+				change.Secret = detectSecret(change.After)
+			}
+
+			if c.onConvert != nil && !c.onConvert(change) {
+				return suppress("vetoed by hook")
+			}
+
+			if change.Secret != "" {
+				c.logger.Printf("Warning: %s: literal looks like a credential (%s); its content is redacted from all output", change.Pos, change.Secret)
+			}
+
+			if first, last := fset.Position(lit.Pos()).Line, fset.Position(lit.End()).Line; first != last {
+				collapsed = append(collapsed, [2]int{first, last})
+			}
+
+			changes = append(changes, change)
+			lit.Value = value
+		}
+
+		return true
+	})
+
+	tf := fset.File(file.Pos())
+	for _, lines := range slices.Backward(collapsed) {
+		collapseLines(tf, lines[0], lines[1])
+	}
+
+	return changes, suppressed, err
+}
+
+// declRange is the byte range of a top-level declaration in the original source.
+type declRange struct {
+	decl       ast.Decl
+	start, end int
+}
+
+// declRanges records the source range of every top-level declaration, including its
+// doc comment and a trailing comment on its last line, which the printer emits with the
+// declaration. It must run before literals are rewritten, because node end positions
+// are derived from literal lengths.
+func declRanges(fset *token.FileSet, file *ast.File, src []byte) []declRange {
+	ranges := make([]declRange, 0, len(file.Decls))
+
+	for _, decl := range file.Decls {
+		start := fset.Position(decl.Pos()).Offset
+		if doc := declDoc(decl); doc != nil {
+			start = fset.Position(doc.Pos()).Offset
+		}
+		end := fset.Position(decl.End()).Offset
+
+		// The scanner strips carriage returns from raw literals, so a declaration ending
+		// in one would otherwise end too early.
+		ast.Inspect(decl, func(n ast.Node) bool {
+			lit, ok := n.(*ast.BasicLit)
+			if ok && lit.End() == decl.End() && isRawLiteral(lit.Value) {
+				offset := fset.Position(lit.Pos()).Offset
+				if closing := bytes.IndexByte(src[offset+1:], '`'); closing >= 0 {
+					end = offset + 1 + closing + 1
+				}
+			}
+
+			return true
+		})
+
+		line := fset.Position(decl.End()).Line
+		for _, cg := range file.Comments {
+			if pos := fset.Position(cg.Pos()); pos.Offset >= end && pos.Line == line {
+				end = fset.Position(cg.End()).Offset
+			}
+		}
+
+		ranges = append(ranges, declRange{decl: decl, start: start, end: end})
+	}
+
+	return ranges
+}
+
+// declDoc returns the doc comment of decl, or nil if it has none.
+func declDoc(decl ast.Decl) *ast.CommentGroup {
+	switch decl := decl.(type) {
+	case *ast.GenDecl:
+		return decl.Doc
+	case *ast.FuncDecl:
+		return decl.Doc
+	default:
+		return nil
+	}
+}
+
+// formatChangedDecls reformats only the declarations that contain a change and splices
+// them back into the original source, leaving the rest of the file byte-for-byte intact.
+func formatChangedDecls(fset *token.FileSet, file *ast.File, src []byte, ranges []declRange, changes []Change) ([]byte, error) {
+	var out bytes.Buffer
+
+	last := 0
+
+	for _, r := range ranges {
+		if !slices.ContainsFunc(changes, func(c Change) bool {
+			return c.Pos.Offset >= r.start && c.Pos.Offset < r.end
+		}) {
+			continue
+		}
+
+		var comments []*ast.CommentGroup
+
+		for _, cg := range file.Comments {
+			if offset := fset.Position(cg.Pos()).Offset; offset >= r.start && offset < r.end {
+				comments = append(comments, cg)
+			}
+		}
+
+		var decl bytes.Buffer
+		if err := format.Node(&decl, fset, &printer.CommentedNode{Node: r.decl, Comments: comments}); err != nil {
+			return nil, fmt.Errorf("format declaration: %w", err)
+		}
+
+		// The printer ends a trailing line comment with a newline, which lies outside the
+		// range.
+		out.Write(src[last:r.start])
+		out.Write(bytes.TrimSuffix(decl.Bytes(), []byte("\n")))
+
+		last = r.end
+	}
+
+	out.Write(src[last:])
+
+	if err := verifyRoundTrip(src, out.Bytes(), changes); err != nil {
+		return nil, fmt.Errorf("verify formatted source: %w", err)
+	}
+
+	if err := verifyCgoPreambles(src, out.Bytes()); err != nil {
+		return nil, fmt.Errorf("verify formatted source: %w", err)
+	}
+
+	return out.Bytes(), nil
+}
+
+// compileOptional compiles pattern, returning nil for an empty pattern.
+func compileOptional(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("compile %q: %w", pattern, err)
+	}
+
+	return re, nil
+}
+
+func isCancelled(ctx context.Context) bool {
+	select {
+	case <-ctx.Done():
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,163 @@
+package quotedconv_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/otakakot/quotedconv/quotedconv"
+)
+
+func TestProcessKeepsDocComments(t *testing.T) {
+	src := "package x\n" +
+		"\n" +
+		"// y is documented.\n" +
+		"var y = `c` // trailing\n" +
+		"\n" +
+		"// F is documented.\n" +
+		"func F() string { return `z` }\n" +
+		"\n" +
+		"// The block is documented.\n" +
+		"const (\n" +
+		"\t// A is documented.\n" +
+		"\tA = `a`\n" +
+		"\tBB = `b` // trailing\n" +
+		")\n"
+
+	want := "package x\n" +
+		"\n" +
+		"// y is documented.\n" +
+		"var y = \"c\" // trailing\n" +
+		"\n" +
+		"// F is documented.\n" +
+		"func F() string { return \"z\" }\n" +
+		"\n" +
+		"// The block is documented.\n" +
+		"const (\n" +
+		"\t// A is documented.\n" +
+		"\tA  = \"a\"\n" +
+		"\tBB = \"b\" // trailing\n" +
+		")\n"
+
+	out, changed, err := quotedconv.Process([]byte(src))
+	if err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+
+	if !changed {
+		t.Fatal("Process reported no change")
+	}
+
+	if string(out) != want {
+		t.Errorf("Process output:\n%s\nwant:\n%s", out, want)
+	}
+}
+
+// convert converts src, the body of a file after its package clause, with opts.
+func convert(t *testing.T, opts quotedconv.Options, src string) (quotedconv.Result, error) {
+	t.Helper()
+
+	c, err := quotedconv.New(quotedconv.WithOptions(opts), quotedconv.WithLogger(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return c.Convert(context.Background(), "x.go", []byte("package x\n\n"+src))
+}
+
+// converted returns the literals converted in src, as their original source text.
+func converted(t *testing.T, opts quotedconv.Options, src string) []string {
+	t.Helper()
+
+	result, err := convert(t, opts, src)
+	if err != nil {
+		t.Fatalf("Convert: %v", err)
+	}
+
+	literals := []string{}
+	for _, change := range result.Changes {
+		literals = append(literals, change.Before)
+	}
+
+	return literals
+}
+
+// conversionTest is a case for converted: want lists the literals converted in src.
+type conversionTest struct {
+	name string
+	src  string
+	want []string
+}
+
+func runConversionTests(t *testing.T, opts quotedconv.Options, tests []conversionTest) {
+	t.Helper()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := converted(t, opts, tt.src); !slices.Equal(got, tt.want) {
+				t.Errorf("converted %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertLines(t *testing.T) {
+	// Lines 3 and 4 hold single-line literals, lines 6 and 7 a forced multi-line one.
+	const src = "var a = `a`\n" +
+		"var b = `b`\n" +
+		"//quotedconv:force\n" +
+		"var m = `m\n" +
+		"n`\n"
+
+	tests := []struct {
+		lines string
+		want  []string
+	}{
+		{lines: "3:3", want: []string{"`a`"}},
+		{lines: "4:", want: []string{"`b`", "`m\nn`"}},
+		{lines: ":4", want: []string{"`a`", "`b`"}},
+		{lines: "6:7", want: []string{"`m\nn`"}},
+		// A literal only partly within the range is left alone.
+		{lines: "6:6", want: []string{}},
+		{lines: "7:9", want: []string{}},
+		{lines: "5:5", want: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.lines, func(t *testing.T) {
+			opts := quotedconv.DefaultOptions()
+			if err := opts.Lines.Set(tt.lines); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := converted(t, opts, src); !slices.Equal(got, tt.want) {
+				t.Errorf("converted %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertStructTags(t *testing.T) {
+	const src = "type T struct {\n" +
+		"\tA int \"json:\\\"a\\\"\"\n" +
+		"\tB int `json:\"b\"`\n" +
+		"\tC int `c`\n" +
+		"\tD int \"d\\n\"\n" +
+		"}\n"
+
+	runConversionTests(t, quotedconv.DefaultOptions(), []conversionTest{
+		{name: "default", src: src, want: []string{}},
+	})
+
+	opts := quotedconv.DefaultOptions()
+	opts.RawTags = true
+
+	result, err := convert(t, opts, src)
+	if err != nil {
+		t.Fatalf("Convert: %v", err)
+	}
+
+	if len(result.Changes) != 1 || result.Changes[0].After != "`json:\"a\"`" {
+		t.Errorf("changes = %v, want only the tag of A converted to `json:\"a\"`", result.Changes)
+	}
+}
@@ -0,0 +1,75 @@
+package quotedconv
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// RewriteRule replaces every match of Pattern in the content of a converted literal
+// with Replacement, which may refer to capture groups as in regexp.Regexp.ReplaceAllString.
+type RewriteRule struct {
+	Pattern     string
+	Replacement string
+}
+
+func (r RewriteRule) String() string {
+	return r.Pattern + "=>" + r.Replacement
+}
+
+type compiledRewrite struct {
+	rule RewriteRule
+	re   *regexp.Regexp
+}
+
+func compileRewrites(rules []RewriteRule) ([]compiledRewrite, error) {
+	compiled := make([]compiledRewrite, 0, len(rules))
+
+	for _, rule := range rules {
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("compile rewrite rule %q: %w", rule, err)
+		}
+
+		compiled = append(compiled, compiledRewrite{rule: rule, re: re})
+	}
+
+	return compiled, nil
+}
+
+// applyRewrites runs the rewrite rules over the content of the converted literal value
+// and returns the new literal in the same quoting style together with the rules that
+// matched. A raw literal whose rewritten content can no longer be raw is left as is.
+func applyRewrites(value string, rewrites []compiledRewrite, opts Options) (string, []string) {
+	if len(rewrites) == 0 {
+		return value, nil
+	}
+
+	content, err := strconv.Unquote(value)
+	if err != nil {
+		return value, nil
+	}
+
+	var applied []string
+
+	for _, rw := range rewrites {
+		if rw.re.MatchString(content) {
+			content = rw.re.ReplaceAllString(content, rw.rule.Replacement)
+			applied = append(applied, rw.rule.String())
+		}
+	}
+
+	if len(applied) == 0 {
+		return value, nil
+	}
+
+	if !isRawLiteral(value) {
+		return opts.Escaping.quote(content), applied
+	}
+
+	if !canBeRaw(content) {
+		return value, nil
+	}
+
+	return "`" + content + "`", applied
+}
@@ -0,0 +1,117 @@
+package quotedconv
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strconv"
+)
+
+// errValueChanged reports converted source in which a string literal no longer has the
+// value it had in the input.
+var errValueChanged = errors.New("string literal value changed")
+
+// VerifyRoundTrip converts src with the default options and reports an error if the
+// result would not parse or would change the value of any string literal.
+//
+// Every conversion is verified this way before a file is written, so for any src that
+// parses, the tool either produces source that parses and in which every string
+// literal has its original value, or it leaves the file untouched and reports an
+// error. The only intended value changes are those made by Options.Rewrites.
+func VerifyRoundTrip(src []byte) error {
+	c, err := NewConverter(DefaultOptions())
+	if err != nil {
+		return err
+	}
+
+	if _, err := c.Convert(context.Background(), "", src); err != nil && !errors.Is(err, ErrFileIgnored) {
+		return err
+	}
+
+	return nil
+}
+
+type literalValue struct {
+	pos   token.Position
+	value string
+}
+
+// verifyRoundTrip checks that out parses and that its string literals, in order, have
+// the values of those in src, except that the literals converted by changes have the
+// value of their replacement. A replacement must keep the value of the original
+// literal unless a rewrite rule changed it.
+func verifyRoundTrip(src, out []byte, changes []Change) error {
+	before, err := stringLiterals(src)
+	if err != nil {
+		return fmt.Errorf("parse source: %w", err)
+	}
+
+	after, err := stringLiterals(out)
+	if err != nil {
+		return fmt.Errorf("reparse formatted source: %w", err)
+	}
+
+	if len(before) != len(after) {
+		return fmt.Errorf("%w: %d string literals became %d", errValueChanged, len(before), len(after))
+	}
+
+	converted := make(map[int]Change, len(changes))
+	for _, c := range changes {
+		converted[c.Pos.Offset] = c
+	}
+
+	for i, lit := range before {
+		want := lit.value
+
+		if c, ok := converted[lit.pos.Offset]; ok {
+			if want, err = strconv.Unquote(c.After); err != nil {
+				return fmt.Errorf("%w at %s: invalid replacement: %w", errValueChanged, lit.pos, err)
+			}
+
+			if len(c.Rewrites) == 0 && want != lit.value {
+				return fmt.Errorf("%w at %s: conversion altered the value", errValueChanged, lit.pos)
+			}
+		}
+
+		if after[i].value != want {
+			return fmt.Errorf("%w at %s", errValueChanged, lit.pos)
+		}
+	}
+
+	return nil
+}
+
+func stringLiterals(src []byte) ([]literalValue, error) {
+	fset := token.NewFileSet()
+
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		lits []literalValue
+		errs []error
+	)
+
+	ast.Inspect(file, func(n ast.Node) bool {
+		lit, ok := n.(*ast.BasicLit)
+		if !ok || lit.Kind != token.STRING {
+			return true
+		}
+
+		value, err := strconv.Unquote(lit.Value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", fset.Position(lit.Pos()), err))
+		}
+
+		lits = append(lits, literalValue{pos: fset.Position(lit.Pos()), value: value})
+
+		return true
+	})
+
+	return lits, errors.Join(errs...)
+}
@@ -0,0 +1,97 @@
+package quotedconv
+
+import (
+	"go/ast"
+	"go/token"
+	"slices"
+)
+
+// scopeTargets returns the positions of the string literals within scope, or nil if
+// every literal is in scope.
+func scopeTargets(file *ast.File, scope Scope) map[token.Pos]bool {
+	if len(scope) == 0 {
+		return nil
+	}
+
+	targets := map[token.Pos]bool{}
+
+	var stack []ast.Node
+
+	ast.Inspect(file, func(n ast.Node) bool {
+		if n == nil {
+			stack = stack[:len(stack)-1]
+
+			return true
+		}
+
+		if lit, ok := n.(*ast.BasicLit); ok && lit.Kind == token.STRING {
+			for _, context := range literalContexts(stack, lit) {
+				if slices.Contains(scope, context) {
+					targets[lit.Pos()] = true
+				}
+			}
+		}
+
+		stack = append(stack, n)
+
+		return true
+	})
+
+	return targets
+}
+
+// literalContexts returns the syntactic contexts of lit, given its ancestors from the
+// outermost. Declarations contain every literal beneath them; the other contexts are
+// decided by the nearest enclosing node, looking through parentheses, concatenations
+// and to the value of a key-value pair.
+func literalContexts(stack []ast.Node, lit *ast.BasicLit) []ScopeContext {
+	var contexts []ScopeContext
+
+	for _, n := range stack {
+		if decl, ok := n.(*ast.GenDecl); ok {
+			switch decl.Tok {
+			case token.CONST:
+				contexts = append(contexts, ScopeConst)
+			case token.VAR:
+				contexts = append(contexts, ScopeVar)
+			}
+		}
+	}
+
+	var child ast.Node = lit
+
+enclosing:
+	for _, n := range slices.Backward(stack) {
+		switch parent := n.(type) {
+		case *ast.ParenExpr:
+		case *ast.BinaryExpr:
+			if parent.Op != token.ADD {
+				break enclosing
+			}
+		case *ast.KeyValueExpr:
+			if parent.Value != child {
+				break enclosing
+			}
+		case *ast.CompositeLit:
+			contexts = append(contexts, ScopeComposite)
+
+			break enclosing
+		case *ast.CallExpr:
+			if parent.Fun != child {
+				contexts = append(contexts, ScopeCallArg)
+			}
+
+			break enclosing
+		case *ast.ReturnStmt:
+			contexts = append(contexts, ScopeReturn)
+
+			break enclosing
+		default:
+			break enclosing
+		}
+
+		child = n
+	}
+
+	return contexts
+}
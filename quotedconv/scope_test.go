@@ -0,0 +1,52 @@
+package quotedconv_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/otakakot/quotedconv/quotedconv"
+)
+
+func TestConvertScope(t *testing.T) {
+	const src = "const c = `c`\n" +
+		"\n" +
+		"var v = `v`\n" +
+		"\n" +
+		"var list = []string{`e`}\n" +
+		"\n" +
+		"func f() string {\n" +
+		"\tg(`arg`, (`paren`))\n" +
+		"\ts := `local`\n" +
+		"\tm := map[string]string{`k`: `val`}\n" +
+		"\t_, _ = s, m\n" +
+		"\treturn `r` + `s`\n" +
+		"}\n" +
+		"\n" +
+		"func g(...string) {}\n"
+
+	tests := []struct {
+		scope string
+		want  []string
+	}{
+		{scope: "const", want: []string{"`c`"}},
+		{scope: "var", want: []string{"`v`", "`e`"}},
+		{scope: "composite", want: []string{"`e`", "`val`"}},
+		{scope: "callarg", want: []string{"`arg`", "`paren`"}},
+		{scope: "return", want: []string{"`r`", "`s`"}},
+		{scope: "const,return", want: []string{"`c`", "`r`", "`s`"}},
+		{scope: "all", want: []string{"`c`", "`v`", "`e`", "`arg`", "`paren`", "`local`", "`k`", "`val`", "`r`", "`s`"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.scope, func(t *testing.T) {
+			opts := quotedconv.DefaultOptions()
+			if err := opts.Scope.Set(tt.scope); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := converted(t, opts, src); !slices.Equal(got, tt.want) {
+				t.Errorf("converted %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
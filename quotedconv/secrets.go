@@ -0,0 +1,40 @@
+package quotedconv
+
+import (
+	"regexp"
+	"strconv"
+)
+
+type secretPattern struct {
+	name string
+	re   *regexp.Regexp
+}
+
+// secretPatterns match common credential formats. Literals matching any of them are
+// still converted, but their content never appears in any output.
+var secretPatterns = []secretPattern{
+	{name: "aws-access-key", re: regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{name: "github-token", re: regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b|\bgithub_pat_[A-Za-z0-9_]{22,}`)},
+	{name: "slack-token", re: regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`)},
+	{name: "google-api-key", re: regexp.MustCompile(`\bAIza[0-9A-Za-z_\-]{35}\b`)},
+	{name: "stripe-key", re: regexp.MustCompile(`\b[rs]k_live_[0-9A-Za-z]{24,}\b`)},
+	{name: "private-key", re: regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----`)},
+	{name: "jwt", re: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}`)},
+}
+
+// detectSecret returns the name of the first credential pattern matched by the value of
+// the literal, or "" if none matches.
+func detectSecret(literal string) string {
+	content, err := strconv.Unquote(literal)
+	if err != nil {
+		content = literal
+	}
+
+	for _, p := range secretPatterns {
+		if p.re.MatchString(content) {
+			return p.name
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,56 @@
+package quotedconv
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Stats summarizes the conversion of a source file.
+type Stats struct {
+	// Literals is the number of converted literals.
+	Literals int
+	// Suppressed is the number of convertible literals left alone by heuristics or
+	// directives, counted if Options.ReportSuppressed is set.
+	Suppressed int
+	// Changed reports whether the output differs from the input.
+	Changed bool
+}
+
+// Convert reads a Go source file from src and writes it to dst with its literals
+// converted, starting from the default options. A Go file can only be parsed as a
+// whole, so src is read to the end before anything is written. If no literal is
+// converted, including in a file carrying a //quotedconv:file-ignore directive, the
+// source is copied unchanged.
+func Convert(dst io.Writer, src io.Reader, opts ...Option) (Stats, error) {
+	stats := Stats{Literals: 0, Suppressed: 0, Changed: false}
+
+	c, err := New(opts...)
+	if err != nil {
+		return stats, err
+	}
+
+	in, err := io.ReadAll(src)
+	if err != nil {
+		return stats, fmt.Errorf("read source: %w", err)
+	}
+
+	result, err := c.Convert(context.Background(), "", in)
+	if err != nil && !errors.Is(err, ErrFileIgnored) {
+		return stats, err
+	}
+
+	stats.Literals, stats.Suppressed = len(result.Changes), len(result.Suppressed)
+
+	out := in
+	if len(result.Changes) > 0 {
+		out, stats.Changed = result.Source, true
+	}
+
+	if _, err := dst.Write(out); err != nil {
+		return stats, fmt.Errorf("write output: %w", err)
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,23 @@
+package quotedconv
+
+import (
+	"go/token"
+)
+
+// Suppression records a literal that the conversion rules allow but a heuristic or
+// directive left alone.
+type Suppression struct {
+	Pos    token.Position
+	Reason string
+}
+
+// convertible reports whether the conversion rules alone, without the content and
+// length heuristics, would convert value.
+func convertible(value string, opts Options) bool {
+	opts.KeepSQL, opts.KeepJSON, opts.KeepPaths = false, false, false
+	opts.MinLen, opts.MaxLen = 0, 0
+
+	_, ok := ConvertLiteral(value, opts)
+
+	return ok
+}
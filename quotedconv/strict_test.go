@@ -0,0 +1,76 @@
+package quotedconv_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/otakakot/quotedconv/quotedconv"
+)
+
+func TestConvertReportsSuppressions(t *testing.T) {
+	const src = "package x\n" +
+		"\n" +
+		"import \"regexp\"\n" +
+		"\n" +
+		"var a = `a` //quotedconv:ignore\n" +
+		"var re = regexp.MustCompile(`b`)\n" +
+		"var cSkip = `c`\n" +
+		"var d = `SELECT 1 FROM t`\n" +
+		"var e = `e\u202e`\n" +
+		"var f = `say \"hi\"`\n" +
+		"var g = `g`\n" +
+		"var v = `veto`\n" +
+		"\n" +
+		"func h() string { return `h` }\n"
+
+	opts := quotedconv.DefaultOptions()
+	opts.ReportSuppressed = true
+	opts.SkipNamePattern = "Skip$"
+	opts.KeepSQL = true
+	opts.Invisible = quotedconv.CharPolicySkip
+	opts.Scope = quotedconv.Scope{quotedconv.ScopeVar}
+
+	c, err := quotedconv.New(
+		quotedconv.WithOptions(opts),
+		quotedconv.WithLogger(nil),
+		quotedconv.OnConvert(func(change quotedconv.Change) bool { return change.Before != "`veto`" }),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := c.Convert(context.Background(), "x.go", []byte(src))
+	if err != nil {
+		t.Fatalf("Convert: %v", err)
+	}
+
+	type suppression struct {
+		line   int
+		reason string
+	}
+
+	got := []suppression{}
+	for _, s := range result.Suppressed {
+		got = append(got, suppression{line: s.Pos.Line, reason: s.Reason})
+	}
+
+	// The literal of f is not convertible under the quote policy, so it is not listed.
+	want := []suppression{
+		{line: 5, reason: "ignore directive"},
+		{line: 6, reason: "argument of a pattern or skipped call"},
+		{line: 7, reason: "name pattern"},
+		{line: 8, reason: "content or length heuristic"},
+		{line: 9, reason: "character policy"},
+		{line: 12, reason: "vetoed by hook"},
+		{line: 14, reason: "out of scope"},
+	}
+
+	if !slices.Equal(got, want) {
+		t.Errorf("suppressed %v, want %v", got, want)
+	}
+
+	if len(result.Changes) != 1 || result.Changes[0].Before != "`g`" {
+		t.Errorf("changes = %v, want only g converted", result.Changes)
+	}
+}
@@ -0,0 +1,198 @@
+// Package quotedconvtest runs the quotedconv conversion over golden corpora, so forks
+// and plugin authors can check custom heuristics and hooks against expected output.
+//
+// A corpus is a txtar archive. Every file ending in .go is an input, converted under
+// its archive name; the expected output is the file of the same name with a .golden
+// suffix, and an input without one is expected to be left unchanged. An optional
+// options.json file sets fields of quotedconv.Options, matched case-insensitively,
+// on top of the defaults:
+//
+//	-- options.json --
+//	{"keepSQL": true}
+//	-- query.go --
+//	package p
+//
+//	var q = `SELECT 1`
+//	var s = `plain`
+//	-- query.go.golden --
+//	package p
+//
+//	var q = `SELECT 1`
+//	var s = "plain"
+//
+// Running the tests with -quotedconvtest.update rewrites the golden files of the
+// archives passed to Run from the current output.
+package quotedconvtest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/txtar"
+
+	"github.com/otakakot/quotedconv/internal/diff"
+	"github.com/otakakot/quotedconv/quotedconv"
+)
+
+const (
+	goldenSuffix = ".golden"
+	optionsFile  = "options.json"
+)
+
+var update = flag.Bool("quotedconvtest.update", false, "rewrite the golden files of quotedconvtest archives")
+
+// Run runs RunArchive on every txtar archive matching the glob pattern, each as a
+// subtest named after the archive, such as Run(t, "testdata/*.txtar"). opts are
+// applied after the options of each archive.
+func Run(t *testing.T, pattern string, opts ...quotedconv.Option) {
+	t.Helper()
+
+	files, err := filepath.Glob(pattern)
+	if err != nil {
+		t.Fatalf("invalid pattern %q: %v", pattern, err)
+	}
+
+	if len(files) == 0 {
+		t.Fatalf("no archives match %s", pattern)
+	}
+
+	for _, file := range files {
+		t.Run(strings.TrimSuffix(filepath.Base(file), filepath.Ext(file)), func(t *testing.T) {
+			t.Helper()
+
+			ar, err := txtar.ParseFile(file)
+			if err != nil {
+				t.Fatalf("read archive: %v", err)
+			}
+
+			if !*update {
+				RunArchive(t, ar, opts...)
+
+				return
+			}
+
+			got, err := Convert(ar, opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := os.WriteFile(file, txtar.Format(got), 0o644); err != nil {
+				t.Fatalf("update archive: %v", err)
+			}
+		})
+	}
+}
+
+// RunArchive converts the inputs of ar and reports every input whose output differs
+// from its golden file, with a diff.
+func RunArchive(t testing.TB, ar *txtar.Archive, opts ...quotedconv.Option) {
+	t.Helper()
+
+	got, err := Convert(ar, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want, have := outputs(ar), outputs(got)
+
+	for _, name := range slices.Sorted(maps.Keys(want)) {
+		if d := diff.Unified(name+goldenSuffix, name, want[name], have[name]); d != "" {
+			t.Errorf("%s: output differs from golden file:\n%s", name, d)
+		}
+	}
+}
+
+// Convert returns a copy of ar whose golden files hold the current output: every input
+// that changes gets a golden file with its converted source, and inputs that do not
+// change get none. opts are applied after the options of the archive.
+func Convert(ar *txtar.Archive, opts ...quotedconv.Option) (*txtar.Archive, error) {
+	archiveOpts, err := archiveOptions(ar)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := quotedconv.New(append([]quotedconv.Option{quotedconv.WithOptions(archiveOpts)}, opts...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &txtar.Archive{Comment: ar.Comment, Files: nil}
+
+	for _, f := range ar.Files {
+		if strings.HasSuffix(f.Name, goldenSuffix) {
+			continue
+		}
+
+		out.Files = append(out.Files, f)
+
+		if !strings.HasSuffix(f.Name, ".go") {
+			continue
+		}
+
+		result, err := c.Convert(context.Background(), f.Name, f.Data)
+		if errors.Is(err, quotedconv.ErrFileIgnored) {
+			continue
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f.Name, err)
+		}
+
+		if len(result.Changes) > 0 {
+			out.Files = append(out.Files, txtar.File{Name: f.Name + goldenSuffix, Data: result.Source})
+		}
+	}
+
+	return out, nil
+}
+
+// outputs maps the inputs of ar to their expected output.
+func outputs(ar *txtar.Archive) map[string][]byte {
+	out := map[string][]byte{}
+
+	for _, f := range ar.Files {
+		if strings.HasSuffix(f.Name, ".go") {
+			if _, ok := out[f.Name]; !ok {
+				out[f.Name] = f.Data
+			}
+		}
+	}
+
+	for _, f := range ar.Files {
+		if name, ok := strings.CutSuffix(f.Name, goldenSuffix); ok {
+			out[name] = f.Data
+		}
+	}
+
+	return out
+}
+
+// archiveOptions returns the default options updated by the options.json file of ar.
+func archiveOptions(ar *txtar.Archive) (quotedconv.Options, error) {
+	opts := quotedconv.DefaultOptions()
+
+	for _, f := range ar.Files {
+		if f.Name != optionsFile {
+			continue
+		}
+
+		dec := json.NewDecoder(bytes.NewReader(f.Data))
+		dec.DisallowUnknownFields()
+
+		if err := dec.Decode(&opts); err != nil {
+			return opts, fmt.Errorf("decode %s: %w", optionsFile, err)
+		}
+	}
+
+	return opts, nil
+}
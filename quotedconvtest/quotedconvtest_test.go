@@ -0,0 +1,11 @@
+package quotedconvtest_test
+
+import (
+	"testing"
+
+	"github.com/otakakot/quotedconv/quotedconvtest"
+)
+
+func TestCorpus(t *testing.T) {
+	quotedconvtest.Run(t, "testdata/*.txtar")
+}
@@ -0,0 +1,481 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"maps"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/otakakot/quotedconv/quotedconv"
+)
+
+// reportSchemaVersion is the newest schema version of the JSON output. Within a schema
+// version fields are only ever added, never renamed, removed or changed in meaning;
+// any incompatible change bumps the version.
+//
+// Version 2 omits the before and after texts of changes unless content is shown;
+// version 1 always carries them and leaves them empty instead.
+const reportSchemaVersion = 2
+
+type report struct {
+	SchemaVersion int          `json:"schemaVersion"`
+	Run           runMetadata  `json:"run"`
+	Processed     int          `json:"processed"`
+	Files         []fileReport `json:"files"`
+	Skipped       []skipReport `json:"skipped,omitempty"`
+	// Suppressed lists the convertible literals left alone by heuristics or directives,
+	// in strict mode.
+	Suppressed []suppressionReport `json:"suppressed,omitempty"`
+	// RewriteCounts maps each rewrite rule to the number of literals it changed.
+	RewriteCounts map[string]int `json:"rewriteCounts,omitempty"`
+	// LiteralTypes counts the convertible literals by the type they are used as.
+	LiteralTypes map[string]int `json:"literalTypes,omitempty"`
+	Errors       []string       `json:"errors,omitempty"`
+}
+
+// runMetadata identifies a run so results of sharded or repeated runs can be
+// correlated and deduplicated.
+type runMetadata struct {
+	ID          string    `json:"id"`
+	ToolVersion string    `json:"toolVersion"`
+	ConfigHash  string    `json:"configHash"`
+	Hostname    string    `json:"hostname"`
+	StartedAt   time.Time `json:"startedAt"`
+	FinishedAt  time.Time `json:"finishedAt"`
+}
+
+func newRunMetadata(opts Options, started time.Time) runMetadata {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = ""
+	}
+
+	return runMetadata{
+		ID:          newRunID(),
+		ToolVersion: toolVersion(),
+		ConfigHash:  configHash(opts),
+		Hostname:    hostname,
+		StartedAt:   started.UTC(),
+		FinishedAt:  time.Now().UTC(),
+	}
+}
+
+func newRunID() string {
+	return rand.Text()
+}
+
+type fileReport struct {
+	Path string `json:"path"`
+	// Package is the import path of the package of the file where it is known, and
+	// its package name otherwise.
+	Package string         `json:"package,omitempty"`
+	Changes []changeReport `json:"changes"`
+	// SecretsDetected is set when a converted literal looks like a credential. The
+	// content of such literals is redacted.
+	SecretsDetected bool   `json:"secretsDetected,omitempty"`
+	BeforeSHA256    string `json:"beforeSha256,omitempty"`
+	AfterSHA256     string `json:"afterSha256,omitempty"`
+	Insertions      int    `json:"insertions"`
+	Deletions       int    `json:"deletions"`
+	Diff            string `json:"-"`
+	// Edits are the changes as Language Server Protocol text edits, for the LSP format.
+	Edits []quotedconv.LSPTextEdit `json:"-"`
+}
+
+type skipReport struct {
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+}
+
+type suppressionReport struct {
+	Path   string `json:"path"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+	Reason string `json:"reason"`
+}
+
+type changeReport struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+	Offset int `json:"offset"`
+	// EndLine, EndColumn and EndOffset locate the byte just after the original literal.
+	EndLine   int     `json:"endLine"`
+	EndColumn int     `json:"endColumn"`
+	EndOffset int     `json:"endOffset"`
+	Rule      string  `json:"rule"`
+	Detail    string  `json:"detail"`
+	Before    *string `json:"before,omitempty"`
+	After     *string `json:"after,omitempty"`
+	// BeforeLength and AfterLength are the byte lengths of the literal texts.
+	BeforeLength int      `json:"beforeLength"`
+	AfterLength  int      `json:"afterLength"`
+	Rewrites     []string `json:"rewrites,omitempty"`
+}
+
+// newChangeReport returns the report of c. Literal texts are only included with
+// opts.ShowContent, and secrets are redacted.
+func newChangeReport(c quotedconv.Change, opts Options) changeReport {
+	beforeLength, afterLength := len(c.Before), len(c.After)
+	c = redacted(c)
+
+	before, after := &c.Before, &c.After
+	if !opts.ShowContent {
+		before, after = nil, nil
+
+		if opts.FormatVersion == 1 {
+			before, after = new(string), new(string)
+		}
+	}
+
+	return changeReport{
+		Line:         c.Pos.Line,
+		Column:       c.Pos.Column,
+		Offset:       c.Pos.Offset,
+		EndLine:      c.End.Line,
+		EndColumn:    c.End.Column,
+		EndOffset:    c.End.Offset,
+		Rule:         c.Rule,
+		Detail:       c.Detail,
+		Before:       before,
+		After:        after,
+		BeforeLength: beforeLength,
+		AfterLength:  afterLength,
+		Rewrites:     c.Rewrites,
+	}
+}
+
+func newReport(opts Options, started time.Time, processed int, results []fileResult, skipped []skippedFile, errs *collectorError) *report {
+	files := make([]fileReport, 0, len(results))
+	rewriteCounts := map[string]int{}
+
+	var suppressed []suppressionReport
+
+	for _, result := range results {
+		for _, s := range result.Suppressed {
+			suppressed = append(suppressed, suppressionReport{Path: result.Path, Line: s.Pos.Line, Column: s.Pos.Column, Reason: s.Reason})
+		}
+
+		if len(result.Changes) == 0 {
+			continue
+		}
+
+		changes := make([]changeReport, 0, len(result.Changes))
+		secrets := false
+
+		for _, c := range result.Changes {
+			secrets = secrets || c.Secret != ""
+
+			changes = append(changes, newChangeReport(c, opts))
+
+			for _, rule := range c.Rewrites {
+				rewriteCounts[rule]++
+			}
+		}
+
+		files = append(files, fileReport{
+			Path:            result.Path,
+			Package:         result.Package,
+			Changes:         changes,
+			SecretsDetected: secrets,
+			BeforeSHA256:    result.BeforeSHA256,
+			AfterSHA256:     result.AfterSHA256,
+			Insertions:      result.Insertions,
+			Deletions:       result.Deletions,
+			Diff:            result.Diff,
+			Edits:           result.Edits,
+		})
+	}
+
+	skips := make([]skipReport, 0, len(skipped))
+	for _, s := range skipped {
+		skips = append(skips, skipReport{Path: s.Path, Reason: s.Reason})
+	}
+
+	errStrings := []string{}
+
+	errs.mu.Lock()
+	for _, err := range errs.errors {
+		errStrings = append(errStrings, err.Error())
+	}
+	errs.mu.Unlock()
+
+	return &report{
+		SchemaVersion: opts.FormatVersion,
+		Run:           newRunMetadata(opts, started),
+		Processed:     processed,
+		Files:         files,
+		Skipped:       skips,
+		Suppressed:    suppressed,
+		RewriteCounts: rewriteCounts,
+		LiteralTypes:  nil,
+		Errors:        errStrings,
+	}
+}
+
+// publishReport delivers the outcome of a run to every configured destination: the
+// report on w, the GitHub step summary, the audit log and the webhook. rep may be nil
+// when the run failed before producing one.
+func (p *Processor) publishReport(ctx context.Context, w io.Writer, rep *report, runErr error) error {
+	opts := p.opts
+
+	if rep != nil {
+		if err := p.writeReport(w, rep); err != nil {
+			return err
+		}
+	}
+
+	if rep != nil && opts.GitHubSummaryPath != "" {
+		if err := appendGitHubSummary(opts.GitHubSummaryPath, rep); err != nil {
+			p.logger.Printf("Warning: GitHub step summary: %v", err)
+		}
+	}
+
+	if rep != nil && opts.AuditLog != "" {
+		if err := appendAuditRecord(opts.AuditLog, rep); err != nil {
+			return fmt.Errorf("audit log: %w", err)
+		}
+	}
+
+	if opts.NotifyURL != "" && (rep != nil || runErr != nil) {
+		summary := rep
+		if summary == nil {
+			summary = &report{SchemaVersion: opts.FormatVersion, Run: newRunMetadata(opts, time.Now()), Processed: 0, Files: nil, Skipped: nil, Suppressed: nil, RewriteCounts: nil, LiteralTypes: nil, Errors: []string{runErr.Error()}}
+		}
+
+		if err := notify(ctx, opts.NotifyURL, summary, opts.NotifySlack); err != nil {
+			p.logger.Printf("Warning: notify %s: %v", opts.NotifyURL, err)
+		}
+	}
+
+	return nil
+}
+
+func (p *Processor) writeReport(w io.Writer, rep *report) error {
+	opts := p.opts
+
+	switch opts.Format {
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.SetEscapeHTML(false)
+
+		if err := enc.Encode(rep); err != nil {
+			return fmt.Errorf("encode report: %w", err)
+		}
+	case FormatMarkdown:
+		if _, err := io.WriteString(w, markdownReport(rep)); err != nil {
+			return fmt.Errorf("write report: %w", err)
+		}
+	case FormatQuickfix:
+		if _, err := io.WriteString(w, quickfixReport(rep)); err != nil {
+			return fmt.Errorf("write report: %w", err)
+		}
+	case FormatLSP:
+		if err := writeWorkspaceEdit(w, rep); err != nil {
+			return err
+		}
+	case FormatText:
+		if opts.Stat {
+			if _, err := io.WriteString(w, diffStatReport(rep)); err != nil {
+				return fmt.Errorf("write report: %w", err)
+			}
+		}
+
+		p.logger.Printf("Run %s (quotedconv %s, config %s, host %s) finished in %s",
+			rep.Run.ID, rep.Run.ToolVersion, shortHash(rep.Run.ConfigHash), rep.Run.Hostname, rep.Run.FinishedAt.Sub(rep.Run.StartedAt).Round(time.Millisecond))
+
+		for _, rule := range slices.Sorted(maps.Keys(rep.RewriteCounts)) {
+			p.logger.Printf("Rewrite rule %s applied to %d literals", rule, rep.RewriteCounts[rule])
+		}
+
+		for _, name := range slices.Sorted(maps.Keys(rep.LiteralTypes)) {
+			p.logger.Printf("Convertible literals used as %s: %d", name, rep.LiteralTypes[name])
+		}
+	}
+
+	return nil
+}
+
+func quickfixReport(rep *report) string {
+	var b strings.Builder
+
+	for _, f := range rep.Files {
+		for _, c := range f.Changes {
+			msg := strings.Join(strings.Fields(c.Rule+": "+c.Detail), " ")
+			fmt.Fprintf(&b, "%s:%d:%d: %s\n", f.Path, c.Line, c.Column, msg)
+		}
+	}
+
+	return b.String()
+}
+
+// writeWorkspaceEdit writes the edits of every changed file as a Language Server
+// Protocol WorkspaceEdit keyed by file URI, which editor integrations can apply to
+// open buffers as is. Like every JSON document it carries the schema version, which
+// clients ignore as an unknown property.
+func writeWorkspaceEdit(w io.Writer, rep *report) error {
+	changes := make(map[string][]quotedconv.LSPTextEdit, len(rep.Files))
+
+	for _, f := range rep.Files {
+		abs, err := filepath.Abs(f.Path)
+		if err != nil {
+			return fmt.Errorf("resolve path: %w", err)
+		}
+
+		uri := url.URL{Scheme: "file", Path: filepath.ToSlash(abs)}
+		changes[uri.String()] = f.Edits
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+
+	if err := enc.Encode(map[string]any{"schemaVersion": rep.SchemaVersion, "changes": changes}); err != nil {
+		return fmt.Errorf("encode workspace edit: %w", err)
+	}
+
+	return nil
+}
+
+func appendGitHubSummary(path string, rep *report) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return fmt.Errorf("open summary file: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := io.WriteString(f, markdownReport(rep)+"\n"); err != nil {
+		return fmt.Errorf("write summary file: %w", err)
+	}
+
+	return nil
+}
+
+// summaryLine describes the outcome of a run in a single line.
+func summaryLine(rep *report) string {
+	total := 0
+	for _, f := range rep.Files {
+		total += len(f.Changes)
+	}
+
+	return fmt.Sprintf("converted %d literals in %d files (%s)", total, len(rep.Files), processedSummary(rep))
+}
+
+func processedSummary(rep *report) string {
+	summary := fmt.Sprintf("%d files processed", rep.Processed)
+
+	if len(rep.Skipped) > 0 {
+		summary += fmt.Sprintf(", %d skipped", len(rep.Skipped))
+	}
+
+	if len(rep.Errors) > 0 {
+		summary += fmt.Sprintf(", %d errors", len(rep.Errors))
+	}
+
+	return summary
+}
+
+func shortHash(hash string) string {
+	return hash[:min(len(hash), 12)]
+}
+
+// markdownLargestChanges is the number of files whose diffs are included in a Markdown report.
+const markdownLargestChanges = 5
+
+func markdownReport(rep *report) string {
+	return markdownBody(rep) + fmt.Sprintf("\n<sub>Run `%s` · quotedconv %s · config `%s` · host `%s` · %s – %s</sub>\n",
+		rep.Run.ID, rep.Run.ToolVersion, shortHash(rep.Run.ConfigHash), rep.Run.Hostname,
+		rep.Run.StartedAt.Format(time.RFC3339), rep.Run.FinishedAt.Format(time.RFC3339))
+}
+
+func markdownBody(rep *report) string {
+	var b strings.Builder
+
+	total := 0
+	for _, f := range rep.Files {
+		total += len(f.Changes)
+	}
+
+	b.WriteString("## quotedconv\n\n")
+	fmt.Fprintf(&b, "Converted **%d** literals in **%d** files (%s).\n", total, len(rep.Files), processedSummary(rep))
+
+	if len(rep.Files) == 0 {
+		return b.String()
+	}
+
+	// Packages are identified by import path where it is known. A package name alone
+	// is only unique within its directory, which also tells apart a package and its
+	// external test package.
+	type packageKey struct {
+		dir  string
+		name string
+	}
+
+	type packageStats struct {
+		packageKey
+
+		files    int
+		literals int
+	}
+
+	var packages []*packageStats
+
+	byPackage := map[packageKey]*packageStats{}
+
+	for _, f := range rep.Files {
+		key := packageKey{dir: filepath.ToSlash(filepath.Dir(f.Path)), name: f.Package}
+
+		stats, ok := byPackage[key]
+		if !ok {
+			stats = &packageStats{packageKey: key, files: 0, literals: 0}
+			byPackage[key] = stats
+			packages = append(packages, stats)
+		}
+
+		stats.files++
+		stats.literals += len(f.Changes)
+	}
+
+	slices.SortFunc(packages, func(a, b *packageStats) int {
+		return cmp.Or(strings.Compare(a.dir, b.dir), strings.Compare(a.name, b.name))
+	})
+
+	b.WriteString("\n| Package | Directory | Files | Literals |\n| --- | --- | ---: | ---: |\n")
+
+	for _, p := range packages {
+		fmt.Fprintf(&b, "| `%s` | `%s` | %d | %d |\n", p.name, p.dir, p.files, p.literals)
+	}
+
+	largest := slices.Clone(rep.Files)
+	slices.SortStableFunc(largest, func(a, b fileReport) int {
+		return len(b.Changes) - len(a.Changes)
+	})
+
+	largest = slices.DeleteFunc(largest, func(f fileReport) bool {
+		return f.Diff == ""
+	})
+
+	if len(largest) == 0 {
+		return b.String()
+	}
+
+	b.WriteString("\n### Largest changes\n")
+
+	for _, f := range largest[:min(len(largest), markdownLargestChanges)] {
+		fmt.Fprintf(&b, "\n<details>\n<summary><code>%s</code> (%d literals)</summary>\n\n", html.EscapeString(f.Path), len(f.Changes))
+		// Go sources may contain triple backticks, so the fence uses four.
+		fmt.Fprintf(&b, "````diff\n%s````\n\n</details>\n", f.Diff)
+	}
+
+	return b.String()
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPayloadsCarrySchemaVersion(t *testing.T) {
+	t.Parallel()
+
+	rep := &report{SchemaVersion: 1, Run: runMetadata{}, Processed: 0, Files: nil, Skipped: nil, Suppressed: nil, RewriteCounts: nil, LiteralTypes: nil, Errors: nil}
+
+	var edit bytes.Buffer
+	if err := writeWorkspaceEdit(&edit, rep); err != nil {
+		t.Fatal(err)
+	}
+
+	bodies := make(chan []byte, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies <- body
+	}))
+	t.Cleanup(srv.Close)
+
+	if err := notify(t.Context(), srv.URL, rep, true); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, body := range map[string][]byte{"workspace edit": edit.Bytes(), "slack payload": <-bodies} {
+		var doc struct {
+			SchemaVersion int `json:"schemaVersion"`
+		}
+		if err := json.Unmarshal(body, &doc); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		if doc.SchemaVersion != 1 {
+			t.Errorf("%s: schemaVersion = %d, want 1 in %s", name, doc.SchemaVersion, body)
+		}
+	}
+}
+
+func TestMarkdownReportGroupsByPackage(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod":          "module example.com/m\n\ngo 1.24\n",
+		"util/a.go":       "package util\n\nvar A = `a`\n",
+		"util/b.go":       "package util\n\nvar B = `b`\n",
+		"util/a_test.go":  "package util_test\n\nvar C = `c`\n",
+		"other/util/c.go": "package util\n\nvar D = `d`\n",
+	})
+	t.Chdir(dir)
+
+	tests := []struct {
+		name string
+		root string
+		want []string
+	}{
+		{
+			name: "directory",
+			root: ".",
+			want: []string{
+				"| `util` | `other/util` | 1 | 1 |",
+				"| `util` | `util` | 2 | 2 |",
+				"| `util_test` | `util` | 1 | 1 |",
+			},
+		},
+		{
+			name: "package pattern",
+			root: "./...",
+			want: []string{
+				"| `example.com/m/other/util` |",
+				"| `example.com/m/util` |",
+				"| `example.com/m/util_test` |",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := defaultOptions()
+			opts.DryRun = true
+
+			p, err := NewProcessor(opts, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rep, err := p.ProcessPaths(t.Context(), []string{tt.root}, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			md := markdownReport(rep)
+			for _, row := range tt.want {
+				if !strings.Contains(md, row) {
+					t.Errorf("markdown report lacks %q:\n%s", row, md)
+				}
+			}
+		})
+	}
+}
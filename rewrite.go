@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/otakakot/quotedconv/quotedconv"
+)
+
+// rewriteRules is a repeatable flag of the form REGEX=>REPLACEMENT.
+type rewriteRules []quotedconv.RewriteRule
+
+func (r *rewriteRules) String() string {
+	rules := make([]string, 0, len(*r))
+	for _, rule := range *r {
+		rules = append(rules, rule.String())
+	}
+
+	return strings.Join(rules, ",")
+}
+
+func (r *rewriteRules) Set(value string) error {
+	pattern, replacement, ok := strings.Cut(value, "=>")
+	if !ok {
+		return fmt.Errorf("rewrite rule %q must have the form REGEX=>REPLACEMENT", value)
+	}
+
+	*r = append(*r, quotedconv.RewriteRule{Pattern: pattern, Replacement: replacement})
+
+	return nil
+}
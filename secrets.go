@@ -0,0 +1,34 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/otakakot/quotedconv/quotedconv"
+)
+
+func redactionMarker(secret string) string {
+	return "[REDACTED " + secret + "]"
+}
+
+// redacted returns c with the literal texts replaced by a redaction marker if the
+// literal looks like a credential.
+func redacted(c quotedconv.Change) quotedconv.Change {
+	if c.Secret != "" {
+		c.Before = redactionMarker(c.Secret)
+		c.After = redactionMarker(c.Secret)
+	}
+
+	return c
+}
+
+// redactText replaces every literal of changes that looks like a credential in text.
+func redactText(text string, changes []quotedconv.Change) string {
+	for _, c := range changes {
+		if c.Secret != "" {
+			text = strings.ReplaceAll(text, c.Before, redactionMarker(c.Secret))
+			text = strings.ReplaceAll(text, c.After, redactionMarker(c.Secret))
+		}
+	}
+
+	return text
+}
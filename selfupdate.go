@@ -0,0 +1,292 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"golang.org/x/mod/semver"
+)
+
+const (
+	// releaseURL is the GitHub API endpoint describing the latest release.
+	releaseURL = "https://api.github.com/repos/otakakot/quotedconv/releases/latest"
+	// checksumsAsset is the release asset listing the SHA-256 of every binary, in the
+	// format written by sha256sum.
+	checksumsAsset = "checksums.txt"
+	// signatureAsset is the base64 Ed25519 signature of checksumsAsset, made with the
+	// key whose public half is releasePublicKey.
+	signatureAsset = checksumsAsset + ".sig"
+
+	selfUpdateTimeout = 5 * time.Minute
+	// maxDownloadSize bounds every download, binaries included.
+	maxDownloadSize = 128 << 20
+)
+
+// releasePublicKey is the base64 Ed25519 public key release checksums are signed with.
+// Release builds set it with -ldflags "-X main.releasePublicKey=...", so it reaches
+// users through the binary they already trust rather than through the release being
+// verified. Builds without it cannot update themselves.
+var releasePublicKey string
+
+type release struct {
+	TagName string         `json:"tag_name"`
+	Assets  []releaseAsset `json:"assets"`
+}
+
+type releaseAsset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+}
+
+// selfUpdate replaces the running binary with the binary of the latest release for
+// this platform, after verifying it against the release checksums and their signature.
+// Unless force is set, it refuses to install an older release, or to replace a build
+// whose version cannot be compared, such as a development build.
+func selfUpdate(ctx context.Context, force bool) error {
+	if releasePublicKey == "" {
+		return errors.New("this build has no release signing key to verify updates with; update it the way it was installed")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, selfUpdateTimeout)
+	defer cancel()
+
+	var rel release
+	if err := getJSON(ctx, releaseURL, &rel); err != nil {
+		return fmt.Errorf("fetch latest release: %w", err)
+	}
+
+	current := toolVersion()
+
+	update, err := checkVersion(current, rel.TagName, force)
+	if err != nil {
+		return err
+	}
+
+	if !update {
+		log.Printf("Already up to date (%s)", current)
+
+		return nil
+	}
+
+	name := releaseAssetName(runtime.GOOS, runtime.GOARCH)
+
+	binaryURL, checksumsURL, signatureURL := "", "", ""
+
+	for _, asset := range rel.Assets {
+		switch asset.Name {
+		case name:
+			binaryURL = asset.URL
+		case checksumsAsset:
+			checksumsURL = asset.URL
+		case signatureAsset:
+			signatureURL = asset.URL
+		}
+	}
+
+	if binaryURL == "" {
+		return fmt.Errorf("release %s has no binary for %s/%s", rel.TagName, runtime.GOOS, runtime.GOARCH)
+	}
+
+	if checksumsURL == "" || signatureURL == "" {
+		return fmt.Errorf("release %s has no signed %s", rel.TagName, checksumsAsset)
+	}
+
+	checksums, err := download(ctx, checksumsURL)
+	if err != nil {
+		return fmt.Errorf("download checksums: %w", err)
+	}
+
+	signature, err := download(ctx, signatureURL)
+	if err != nil {
+		return fmt.Errorf("download checksum signature: %w", err)
+	}
+
+	if err := verifySignature(releasePublicKey, checksums, signature); err != nil {
+		return fmt.Errorf("%s of release %s: %w", checksumsAsset, rel.TagName, err)
+	}
+
+	want, err := lookupChecksum(checksums, name)
+	if err != nil {
+		return err
+	}
+
+	binary, err := download(ctx, binaryURL)
+	if err != nil {
+		return fmt.Errorf("download binary: %w", err)
+	}
+
+	if got := sha256.Sum256(binary); hex.EncodeToString(got[:]) != want {
+		return fmt.Errorf("checksum mismatch for %s", name)
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("locate executable: %w", err)
+	}
+
+	if exe, err = filepath.EvalSymlinks(exe); err != nil {
+		return fmt.Errorf("locate executable: %w", err)
+	}
+
+	if err := replaceExecutable(exe, binary); err != nil {
+		return fmt.Errorf("replace executable: %w", err)
+	}
+
+	log.Printf("Updated %s from %s to %s", exe, current, rel.TagName)
+
+	return nil
+}
+
+// checkVersion reports whether the release latest should replace the build current.
+// Releases older than current are refused unless force is set, and so are builds whose
+// version is not a semantic version, such as "(devel)", as they cannot be compared.
+func checkVersion(current, latest string, force bool) (bool, error) {
+	if !semver.IsValid(latest) {
+		return false, fmt.Errorf("latest release %q is not a semantic version", latest)
+	}
+
+	if !semver.IsValid(current) {
+		if !force {
+			return false, fmt.Errorf("cannot compare this build, version %s, with release %s; use -force to install the release anyway", current, latest)
+		}
+
+		return true, nil
+	}
+
+	switch c := semver.Compare(latest, current); {
+	case c == 0:
+		return false, nil
+	case c < 0 && !force:
+		return false, fmt.Errorf("latest release %s is older than this build, version %s; use -force to downgrade", latest, current)
+	default:
+		return true, nil
+	}
+}
+
+// verifySignature checks that signature, a base64 Ed25519 signature, signs data with
+// publicKey, a base64 Ed25519 public key.
+func verifySignature(publicKey string, data, signature []byte) error {
+	key, err := base64.StdEncoding.DecodeString(publicKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return errors.New("invalid release signing key")
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature)))
+	if err != nil {
+		return fmt.Errorf("decode signature: %w", err)
+	}
+
+	if !ed25519.Verify(ed25519.PublicKey(key), data, sig) {
+		return errors.New("signature verification failed")
+	}
+
+	return nil
+}
+
+// replaceExecutable replaces the executable exe with binary. Renaming a verified
+// temporary file over it never leaves a partially written binary behind. Windows
+// refuses to replace a running executable but allows renaming it, so there it is first
+// moved aside to exe.old, which is removed by the next update.
+func replaceExecutable(exe string, binary []byte) error {
+	if runtime.GOOS != "windows" {
+		return writeFileDurable(exe, binary, 0o755)
+	}
+
+	old := exe + ".old"
+	if err := os.Remove(old); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("remove previous executable: %w", err)
+	}
+
+	if err := os.Rename(exe, old); err != nil {
+		return fmt.Errorf("move running executable aside: %w", err)
+	}
+
+	if err := writeFileDurable(exe, binary, 0o755); err != nil {
+		if restoreErr := os.Rename(old, exe); restoreErr != nil {
+			return fmt.Errorf("%w; restore %s: %w", err, old, restoreErr)
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func releaseAssetName(goos, goarch string) string {
+	name := "quotedconv_" + goos + "_" + goarch
+	if goos == "windows" {
+		name += ".exe"
+	}
+
+	return name
+}
+
+// lookupChecksum returns the hex SHA-256 recorded for name in sha256sum output.
+func lookupChecksum(checksums []byte, name string) (string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(checksums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == name {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+
+	return "", fmt.Errorf("no checksum for %s", name)
+}
+
+func getJSON(ctx context.Context, url string, v any) error {
+	body, err := download(ctx, url)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+
+	return nil
+}
+
+func download(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("get: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxDownloadSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("read body: %w", err)
+	}
+
+	if len(body) > maxDownloadSize {
+		return nil, fmt.Errorf("response larger than %d bytes", maxDownloadSize)
+	}
+
+	return body, nil
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCheckVersion(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		current string
+		latest  string
+		force   bool
+		update  bool
+		wantErr bool
+	}{
+		{name: "newer", current: "v1.2.0", latest: "v1.3.0", update: true},
+		{name: "same", current: "v1.3.0", latest: "v1.3.0"},
+		{name: "older", current: "v1.4.0", latest: "v1.3.0", wantErr: true},
+		{name: "older forced", current: "v1.4.0", latest: "v1.3.0", force: true, update: true},
+		{name: "pseudo-version after release", current: "v1.3.1-0.20260101000000-abcdef123456", latest: "v1.3.0", wantErr: true},
+		{name: "pseudo-version before release", current: "v1.2.1-0.20260101000000-abcdef123456", latest: "v1.3.0", update: true},
+		{name: "devel", current: "(devel)", latest: "v1.3.0", wantErr: true},
+		{name: "devel forced", current: "(devel)", latest: "v1.3.0", force: true, update: true},
+		{name: "invalid release", current: "v1.2.0", latest: "latest", force: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			update, err := checkVersion(tt.current, tt.latest, tt.force)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("checkVersion(%q, %q, %v) error = %v, wantErr %v", tt.current, tt.latest, tt.force, err, tt.wantErr)
+			}
+
+			if update != tt.update {
+				t.Errorf("checkVersion(%q, %q, %v) = %v, want %v", tt.current, tt.latest, tt.force, update, tt.update)
+			}
+		})
+	}
+}
+
+func TestVerifySignature(t *testing.T) {
+	t.Parallel()
+
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key := base64.StdEncoding.EncodeToString(public)
+	checksums := []byte("0123  quotedconv_linux_amd64\n")
+	signature := []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(private, checksums)) + "\n")
+
+	if err := verifySignature(key, checksums, signature); err != nil {
+		t.Errorf("valid signature: %v", err)
+	}
+
+	if err := verifySignature(key, []byte("4567  quotedconv_linux_amd64\n"), signature); err == nil {
+		t.Error("tampered checksums verified")
+	}
+
+	otherPublic, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := verifySignature(base64.StdEncoding.EncodeToString(otherPublic), checksums, signature); err == nil {
+		t.Error("signature verified with another key")
+	}
+
+	if err := verifySignature("not a key", checksums, signature); err == nil {
+		t.Error("signature verified with an invalid key")
+	}
+}
+
+func TestDownloadLimit(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/large" {
+			w.Write([]byte(strings.Repeat("x", maxDownloadSize+1)))
+
+			return
+		}
+
+		w.Write([]byte("small"))
+	}))
+	t.Cleanup(srv.Close)
+
+	body, err := download(t.Context(), srv.URL+"/small")
+	if err != nil || string(body) != "small" {
+		t.Errorf("download(small) = %q, %v", body, err)
+	}
+
+	if _, err := download(t.Context(), srv.URL+"/large"); err == nil {
+		t.Error("download(large) succeeded")
+	}
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/otakakot/quotedconv/quotedconv"
+)
+
+// maxRequestSize bounds the source accepted by /convert and /check.
+const maxRequestSize = 16 << 20
+
+// shutdownTimeout bounds how long the HTTP server waits for open requests once the
+// drain has finished.
+const shutdownTimeout = 5 * time.Second
+
+// Serve runs the tool as a long-lived service listening on addr. It serves /healthz,
+// which reports whether the process is alive, /readyz, which reports whether it
+// accepts work, and the conversion endpoints POST /convert and POST /check. Any roots
+// are watched as in Watch.
+//
+// Cancelling ctx starts the drain: /readyz starts failing, new conversion requests
+// are rejected, no new batch is started, a batch that is already in flight is finished
+// and its report published, and then the HTTP server is shut down.
+func (p *Processor) Serve(ctx context.Context, addr string, roots []string, numWorkers int, w io.Writer) error {
+	var ready atomic.Bool
+
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("listen: %w", err)
+	}
+
+	srv := &http.Server{Handler: p.serveMux(&ready), ReadHeaderTimeout: 10 * time.Second}
+
+	serveErr := make(chan error, 1)
+
+	go func() {
+		serveErr <- srv.Serve(ln)
+	}()
+
+	p.logger.Printf("Serving on %s", ln.Addr())
+
+	ready.Store(true)
+
+	// In-flight work must survive the cancellation that starts the drain.
+	work := context.WithoutCancel(ctx)
+
+	stop, drain := context.WithCancel(ctx)
+	defer drain()
+
+	watchErr := make(chan error, 1)
+
+	go func() {
+		if len(roots) == 0 {
+			watchErr <- nil
+
+			return
+		}
+
+		watchErr <- p.watch(stop, work, roots, numWorkers, w)
+	}()
+
+	var errs []error
+
+	select {
+	case err := <-serveErr:
+		errs = append(errs, fmt.Errorf("serve: %w", err))
+	case <-ctx.Done():
+	}
+
+	drain()
+	ready.Store(false)
+	p.logger.Printf("Draining")
+
+	if err := <-watchErr; err != nil {
+		errs = append(errs, err)
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(work, shutdownTimeout)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		errs = append(errs, fmt.Errorf("shutdown: %w", err))
+	}
+
+	p.logger.Printf("Drained")
+
+	return errors.Join(errs...)
+}
+
+// serveMux returns the handler of Serve. While ready is unset, /readyz and the
+// conversion endpoints answer 503; conversions already in progress are finished.
+func (p *Processor) serveMux(ready *atomic.Bool) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, _ *http.Request) {
+		io.WriteString(w, "ok\n")
+	})
+	mux.HandleFunc("GET /readyz", func(w http.ResponseWriter, _ *http.Request) {
+		if !ready.Load() {
+			http.Error(w, "draining", http.StatusServiceUnavailable)
+
+			return
+		}
+
+		io.WriteString(w, "ok\n")
+	})
+
+	for path, withSource := range map[string]bool{"POST /convert": true, "POST /check": false} {
+		mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+			if !ready.Load() {
+				w.Header().Set("Connection", "close")
+				http.Error(w, "draining", http.StatusServiceUnavailable)
+
+				return
+			}
+
+			p.serveConvert(w, r, withSource)
+		})
+	}
+
+	return mux
+}
+
+// convertResponse is the response of /convert and /check.
+type convertResponse struct {
+	SchemaVersion int `json:"schemaVersion"`
+	// Changed reports whether the source would change.
+	Changed bool `json:"changed"`
+	// Source is the converted source; /check omits it.
+	Source  *string        `json:"source,omitempty"`
+	Changes []changeReport `json:"changes"`
+}
+
+// serveConvert converts the Go source in the request body and responds with the
+// changes, and with the converted source if withSource is set. The optional filename
+// query parameter names the source in errors. Source that does not parse is a 400.
+func (p *Processor) serveConvert(w http.ResponseWriter, r *http.Request, withSource bool) {
+	src, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestSize))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+
+		status := http.StatusBadRequest
+		if errors.As(err, &tooLarge) {
+			status = http.StatusRequestEntityTooLarge
+		}
+
+		http.Error(w, fmt.Sprintf("read request: %v", err), status)
+
+		return
+	}
+
+	filename := r.URL.Query().Get("filename")
+
+	result, err := p.converter.Convert(r.Context(), filename, src)
+	if err != nil && !errors.Is(err, quotedconv.ErrFileIgnored) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	resp := convertResponse{SchemaVersion: p.opts.FormatVersion, Changed: false, Source: nil, Changes: []changeReport{}}
+
+	out := src
+	if len(result.Changes) > 0 && needsWrite(src, result.Source) {
+		out, resp.Changed = result.Source, true
+
+		for _, c := range result.Changes {
+			resp.Changes = append(resp.Changes, newChangeReport(c, p.opts))
+		}
+	}
+
+	if withSource {
+		source := string(out)
+		resp.Source = &source
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		p.logger.Printf("Warning: write response: %v", err)
+	}
+}
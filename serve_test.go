@@ -0,0 +1,64 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestServeRejectsWorkWhileDraining(t *testing.T) {
+	t.Parallel()
+
+	p, err := NewProcessor(defaultOptions(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var ready atomic.Bool
+
+	srv := httptest.NewServer(p.serveMux(&ready))
+	t.Cleanup(srv.Close)
+
+	request := func(method, path string) int {
+		t.Helper()
+
+		req, err := http.NewRequestWithContext(t.Context(), method, srv.URL+path, strings.NewReader("package x\n\nvar s = `a`\n"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+
+		return resp.StatusCode
+	}
+
+	for _, drain := range []bool{false, true} {
+		ready.Store(!drain)
+
+		want := http.StatusOK
+		if drain {
+			want = http.StatusServiceUnavailable
+		}
+
+		for _, path := range []string{"/readyz", "/convert", "/check"} {
+			method := http.MethodPost
+			if path == "/readyz" {
+				method = http.MethodGet
+			}
+
+			if got := request(method, path); got != want {
+				t.Errorf("%s %s with draining %v = %d, want %d", method, path, drain, got, want)
+			}
+		}
+
+		if got := request(http.MethodGet, "/healthz"); got != http.StatusOK {
+			t.Errorf("GET /healthz with draining %v = %d, want %d", drain, got, http.StatusOK)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// RenderSnippet renders the source around the length bytes at offset in src, with
+// contextLines lines before and after, line numbers, and a caret line underlining the
+// marked bytes. Marks spanning several lines are underlined up to the end of their
+// first line. Tabs are preserved in the caret line so the carets align with the source.
+func RenderSnippet(src []byte, offset, length, contextLines int) string {
+	if offset < 0 || offset > len(src) {
+		return ""
+	}
+
+	lines := bytes.SplitAfter(src, []byte("\n"))
+
+	line, lineStart := 0, 0
+	for line < len(lines)-1 && lineStart+len(lines[line]) <= offset {
+		lineStart += len(lines[line])
+		line++
+	}
+
+	first := max(line-contextLines, 0)
+	last := min(line+contextLines, len(lines)-1)
+
+	for last > line && len(bytes.TrimRight(lines[last], "\n")) == 0 && last == len(lines)-1 {
+		last--
+	}
+
+	width := len(fmt.Sprint(last + 1))
+
+	var b strings.Builder
+
+	for i := first; i <= last; i++ {
+		text := strings.TrimRight(string(lines[i]), "\r\n")
+		fmt.Fprintf(&b, "%*d | %s\n", width, i+1, text)
+
+		if i != line {
+			continue
+		}
+
+		column := offset - lineStart
+		end := min(column+max(length, 1), len(text))
+
+		var caret strings.Builder
+
+		for _, r := range text[:min(column, len(text))] {
+			if r == '\t' {
+				caret.WriteByte('\t')
+			} else {
+				caret.WriteByte(' ')
+			}
+		}
+
+		caret.WriteString(strings.Repeat("^", max(end-column, 1)))
+		fmt.Fprintf(&b, "%*s | %s\n", width, "", caret.String())
+	}
+
+	return b.String()
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+// readSource returns the contents of filename in fsys. Files of at least threshold
+// bytes are memory-mapped when fsys is the operating system's and the platform
+// supports it, falling back to fsys.ReadFile otherwise. The returned release function
+// must be called once the contents are no longer used; it is safe to call more than
+// once.
+func readSource(fsys FS, filename string, threshold int64) ([]byte, func(), error) {
+	noop := func() {}
+
+	if _, ok := fsys.(osFS); ok && threshold > 0 {
+		info, err := os.Stat(filename)
+		if err != nil {
+			return nil, noop, fmt.Errorf("stat file: %w", err)
+		}
+
+		if info.Size() >= threshold {
+			if data, unmap, err := mmapFile(filename, info.Size()); err == nil {
+				var once sync.Once
+
+				return data, func() { once.Do(unmap) }, nil
+			}
+		}
+	}
+
+	data, err := fsys.ReadFile(filename)
+	if err != nil {
+		return nil, noop, fmt.Errorf("read file: %w", err)
+	}
+
+	return data, noop, nil
+}
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package main
+
+// syncDir is a no-op where directories cannot be opened for syncing; renames are
+// durable once the call returns on those platforms' native filesystems.
+func syncDir(string) error {
+	return nil
+}
@@ -0,0 +1,15 @@
+//go:build unix
+
+package main
+
+import "os"
+
+func syncDir(dir string) error {
+	f, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return f.Sync()
+}
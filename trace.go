@@ -0,0 +1,268 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/otakakot/quotedconv/quotedconv"
+)
+
+const (
+	// traceBatchSize is the number of spans sent per OTLP request.
+	traceBatchSize = 512
+	// traceBufferSize bounds the spans held between flushes; further spans are dropped.
+	traceBufferSize = 1 << 16
+
+	traceExportTimeout = 10 * time.Second
+)
+
+// tracer records OpenTelemetry spans and exports them to an OTLP/HTTP collector using
+// the JSON encoding. It is configured with the standard OTEL_EXPORTER_OTLP_* and
+// OTEL_SERVICE_NAME environment variables. A nil tracer records nothing, so call
+// sites need no checks when tracing is off.
+type tracer struct {
+	endpoint string
+	headers  map[string]string
+	service  string
+	logger   quotedconv.Logger
+
+	mu      sync.Mutex
+	spans   []*span
+	dropped int
+}
+
+// newTracerFromEnv returns a tracer exporting to the configured OTLP endpoint, or nil
+// when no endpoint is configured. Export failures are reported to logger.
+func newTracerFromEnv(logger quotedconv.Logger) *tracer {
+	endpoint := os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT")
+	if endpoint == "" {
+		base := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+		if base == "" {
+			return nil
+		}
+
+		endpoint = strings.TrimSuffix(base, "/") + "/v1/traces"
+	}
+
+	headers := map[string]string{}
+
+	for _, header := range strings.Split(os.Getenv("OTEL_EXPORTER_OTLP_HEADERS"), ",") {
+		if key, value, ok := strings.Cut(header, "="); ok {
+			headers[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+
+	service := os.Getenv("OTEL_SERVICE_NAME")
+	if service == "" {
+		service = "quotedconv"
+	}
+
+	return &tracer{endpoint: endpoint, headers: headers, service: service, logger: logger}
+}
+
+type span struct {
+	tracer   *tracer
+	name     string
+	traceID  string
+	spanID   string
+	parentID string
+	start    time.Time
+	end      time.Time
+	attrs    map[string]string
+	err      error
+}
+
+type spanKey struct{}
+
+// start begins a span named name as a child of the span in ctx, or as the root of a
+// new trace.
+func (t *tracer) start(ctx context.Context, name string, attrs ...string) (context.Context, *span) {
+	if t == nil {
+		return ctx, nil
+	}
+
+	s := &span{tracer: t, name: name, spanID: randomHex(8), start: time.Now(), attrs: map[string]string{}}
+
+	if parent, ok := ctx.Value(spanKey{}).(*span); ok {
+		s.traceID, s.parentID = parent.traceID, parent.spanID
+	} else {
+		s.traceID = randomHex(16)
+	}
+
+	for i := 0; i+1 < len(attrs); i += 2 {
+		s.attrs[attrs[i]] = attrs[i+1]
+	}
+
+	return context.WithValue(ctx, spanKey{}, s), s
+}
+
+// phase is a quotedconv.Tracer recording a span for a phase of converting a file.
+func (t *tracer) phase(ctx context.Context, name string) func(error) {
+	_, s := t.start(ctx, name)
+
+	return func(err error) {
+		s.fail(err)
+		s.finish()
+	}
+}
+
+// fail marks the span as failed with err, if err is not nil.
+func (s *span) fail(err error) {
+	if s != nil && err != nil {
+		s.err = err
+	}
+}
+
+func (s *span) finish() {
+	if s == nil {
+		return
+	}
+
+	s.end = time.Now()
+
+	t := s.tracer
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(t.spans) >= traceBufferSize {
+		t.dropped++
+
+		return
+	}
+
+	t.spans = append(t.spans, s)
+}
+
+// flush exports the finished spans. Export failures are logged, never returned: tracing
+// must not fail a run.
+func (t *tracer) flush(ctx context.Context) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	spans, dropped := t.spans, t.dropped
+	t.spans, t.dropped = nil, 0
+	t.mu.Unlock()
+
+	if dropped > 0 {
+		t.logger.Printf("Warning: tracing: dropped %d spans", dropped)
+	}
+
+	// The run context may already be cancelled; the spans should still go out.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), traceExportTimeout)
+	defer cancel()
+
+	for batch := range slices.Chunk(spans, traceBatchSize) {
+		if err := t.export(ctx, batch); err != nil {
+			t.logger.Printf("Warning: tracing: export to %s: %v", t.endpoint, err)
+
+			return
+		}
+	}
+}
+
+type otlpKeyValue struct {
+	Key   string            `json:"key"`
+	Value map[string]string `json:"value"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              int            `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Status            map[string]any `json:"status,omitempty"`
+}
+
+func (t *tracer) export(ctx context.Context, spans []*span) error {
+	encoded := make([]otlpSpan, 0, len(spans))
+
+	for _, s := range spans {
+		o := otlpSpan{
+			TraceID:           s.traceID,
+			SpanID:            s.spanID,
+			ParentSpanID:      s.parentID,
+			Name:              s.name,
+			Kind:              1, // SPAN_KIND_INTERNAL
+			StartTimeUnixNano: strconv.FormatInt(s.start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(s.end.UnixNano(), 10),
+			Attributes:        nil,
+			Status:            nil,
+		}
+
+		for key, value := range s.attrs {
+			o.Attributes = append(o.Attributes, otlpKeyValue{Key: key, Value: map[string]string{"stringValue": value}})
+		}
+
+		if s.err != nil {
+			o.Status = map[string]any{"code": 2, "message": s.err.Error()} // STATUS_CODE_ERROR
+		}
+
+		encoded = append(encoded, o)
+	}
+
+	payload := map[string]any{
+		"resourceSpans": []any{map[string]any{
+			"resource": map[string]any{"attributes": []otlpKeyValue{
+				{Key: "service.name", Value: map[string]string{"stringValue": t.service}},
+				{Key: "service.version", Value: map[string]string{"stringValue": toolVersion()}},
+			}},
+			"scopeSpans": []any{map[string]any{
+				"scope": map[string]string{"name": "github.com/otakakot/quotedconv"},
+				"spans": encoded,
+			}},
+		}},
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("encode spans: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	for key, value := range t.headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("post: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+
+	return nil
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/tools/txtar"
+
+	"github.com/otakakot/quotedconv/quotedconv"
+)
+
+// FilterTxtar converts the Go files of the txtar archive read from r and writes the
+// archive with the converted files to w. The comment and all other files are copied
+// unchanged, so synthetic multi-file inputs can be processed without a directory tree.
+func (p *Processor) FilterTxtar(ctx context.Context, r io.Reader, w io.Writer) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("read archive: %w", err)
+	}
+
+	ar := txtar.Parse(data)
+
+	for i, f := range ar.Files {
+		if !strings.HasSuffix(f.Name, ".go") {
+			continue
+		}
+
+		result, err := p.converter.Convert(ctx, f.Name, f.Data)
+		if errors.Is(err, quotedconv.ErrFileIgnored) {
+			continue
+		}
+
+		if err != nil {
+			return fmt.Errorf("%s: %w", f.Name, err)
+		}
+
+		if len(result.Changes) == 0 || !needsWrite(f.Data, result.Source) {
+			continue
+		}
+
+		p.logger.Printf("Converted: %s", f.Name)
+
+		for _, c := range result.Changes {
+			p.logger.Printf("  %s", c)
+		}
+
+		ar.Files[i].Data = result.Source
+	}
+
+	if _, err := w.Write(txtar.Format(ar)); err != nil {
+		return fmt.Errorf("write archive: %w", err)
+	}
+
+	return nil
+}
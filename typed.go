@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"slices"
+
+	"github.com/otakakot/quotedconv/quotedconv"
+	"golang.org/x/tools/go/packages"
+)
+
+// literalKey identifies a literal by the absolute path of its file and its offset.
+type literalKey struct {
+	file   string
+	offset int
+}
+
+// withTypedSkips loads type information for the packages containing files and returns
+// a context under which the converter leaves alone the literals used as one of
+// opts.SkipTypes. It returns ctx unchanged if no types are to be skipped.
+func (p *Processor) withTypedSkips(ctx context.Context, files []string) (context.Context, error) {
+	if len(p.skipTypes) == 0 {
+		return ctx, nil
+	}
+
+	_, span := p.tracer.start(ctx, "types")
+	defer span.finish()
+
+	// Directories are loaded per module, as go list resolves them relative to one.
+	dirs := map[string][]string{}
+
+	for _, file := range files {
+		abs, err := filepath.Abs(file)
+		if err != nil {
+			return ctx, fmt.Errorf("resolve path: %w", err)
+		}
+
+		dir := filepath.Dir(abs)
+		root := moduleRoot(p.fsys, dir)
+
+		if !slices.Contains(dirs[root], dir) {
+			dirs[root] = append(dirs[root], dir)
+		}
+	}
+
+	skips := map[literalKey]bool{}
+
+	for root, patterns := range dirs {
+		cfg := &packages.Config{
+			Mode:    packages.NeedName | packages.NeedFiles | packages.NeedImports | packages.NeedDeps | packages.NeedSyntax | packages.NeedTypes | packages.NeedTypesInfo,
+			Context: ctx,
+			Dir:     root,
+			Tests:   true,
+			Overlay: p.overlay,
+		}
+
+		pkgs, err := packages.Load(cfg, patterns...)
+		if err != nil {
+			span.fail(err)
+
+			return ctx, fmt.Errorf("load packages: %w", err)
+		}
+
+		for _, pkg := range pkgs {
+			p.collectTypedLiterals(pkg, skips)
+		}
+	}
+
+	return quotedconv.WithSkip(ctx, func(pos token.Position) bool {
+		abs, err := filepath.Abs(pos.Filename)
+
+		return err == nil && skips[literalKey{file: abs, offset: pos.Offset}]
+	}, "skipped type"), nil
+}
+
+// collectTypedLiterals records the string literals in pkg whose type, after implicit
+// or explicit conversion, is one of p.skipTypes.
+func (p *Processor) collectTypedLiterals(pkg *packages.Package, skips map[literalKey]bool) {
+	if pkg.TypesInfo == nil {
+		return
+	}
+
+	for _, file := range pkg.Syntax {
+		ast.Inspect(file, func(n ast.Node) bool {
+			var lit *ast.BasicLit
+
+			var typ types.Type
+
+			switch n := n.(type) {
+			case *ast.CallExpr:
+				// A conversion such as SQL(`...`).
+				if len(n.Args) == 1 && pkg.TypesInfo.Types[n.Fun].IsType() {
+					lit, _ = ast.Unparen(n.Args[0]).(*ast.BasicLit)
+					typ = pkg.TypesInfo.Types[n.Fun].Type
+				}
+			case *ast.BasicLit:
+				lit, typ = n, pkg.TypesInfo.Types[n].Type
+			}
+
+			if lit == nil || lit.Kind != token.STRING || !p.skipsType(typ) {
+				return true
+			}
+
+			pos := pkg.Fset.Position(lit.Pos())
+			skips[literalKey{file: pos.Filename, offset: pos.Offset}] = true
+
+			return true
+		})
+	}
+}
+
+// skipsType reports whether typ is a named type listed in p.skipTypes, by import path or
+// package name.
+func (p *Processor) skipsType(typ types.Type) bool {
+	named, ok := typ.(*types.Named)
+	if !ok || named.Obj().Pkg() == nil {
+		return false
+	}
+
+	obj := named.Obj()
+
+	return p.skipTypes[obj.Pkg().Path()+"."+obj.Name()] || p.skipTypes[obj.Pkg().Name()+"."+obj.Name()]
+}
+
+// moduleRoot returns the nearest directory at or above dir containing a go.mod file in
+// fsys, or dir itself if there is none.
+func moduleRoot(fsys FS, dir string) string {
+	for d := dir; ; {
+		if _, err := fsys.Stat(filepath.Join(d, "go.mod")); err == nil {
+			return d
+		}
+
+		parent := filepath.Dir(d)
+		if parent == d {
+			return dir
+		}
+
+		d = parent
+	}
+}
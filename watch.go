@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// Watch polls roots for modified Go files and converts them in batches. Changes are
+// collected until no further modification has been seen for opts.WatchDebounce, so a
+// burst of saves or a branch switch results in a single run. Each batch report is
+// written to w. Watch returns when ctx is cancelled.
+func (p *Processor) Watch(ctx context.Context, roots []string, numWorkers int, w io.Writer) error {
+	return p.watch(ctx, ctx, roots, numWorkers, w)
+}
+
+// watch runs the watch loop until stop is cancelled. Batches run under ctx, so when
+// stop and ctx differ a batch that is already in flight is finished and its report
+// published before watch returns.
+func (p *Processor) watch(stop, ctx context.Context, roots []string, numWorkers int, w io.Writer) error {
+	watched := strings.Join(roots, ", ")
+
+	snapshot, err := p.scan(ctx, roots)
+	if err != nil {
+		return err
+	}
+
+	p.logger.Printf("Watching %s", watched)
+
+	ticker := time.NewTicker(p.opts.WatchInterval)
+	defer ticker.Stop()
+
+	pending := map[string]bool{}
+
+	var lastEvent time.Time
+
+	for {
+		select {
+		case <-stop.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		current, err := p.scan(ctx, roots)
+		if err != nil {
+			if isCancelled(ctx) {
+				return nil
+			}
+
+			p.logger.Printf("Warning: scan %s: %v", watched, err)
+
+			continue
+		}
+
+		modified := false
+
+		for path, state := range current {
+			if old, ok := snapshot[path]; !ok || !old.modTime.Equal(state.modTime) || old.size != state.size {
+				pending[path] = true
+				modified = true
+			}
+		}
+
+		snapshot = current
+
+		if modified {
+			lastEvent = time.Now()
+
+			continue
+		}
+
+		if len(pending) == 0 || time.Since(lastEvent) < p.opts.WatchDebounce {
+			continue
+		}
+
+		files := make([]string, 0, len(pending))
+		for path := range pending {
+			if _, ok := current[path]; ok {
+				files = append(files, path)
+			}
+		}
+
+		clear(pending)
+		slices.Sort(files)
+
+		batchCtx, span := p.tracer.start(ctx, "batch", "roots", watched)
+		rep, err := p.processFiles(batchCtx, files, numWorkers, time.Now())
+		span.fail(err)
+		span.finish()
+		p.tracer.flush(ctx)
+
+		if err != nil && !errors.Is(err, context.Canceled) {
+			p.logger.Printf("Error: %v", err)
+		}
+
+		if err := p.publishReport(ctx, w, rep, err); err != nil {
+			return err
+		}
+
+		// Our own writes must not trigger another batch, but edits saved while the batch
+		// ran must, so the snapshot taken before it is kept apart from the written files.
+		p.absorbWrites(snapshot, rep)
+	}
+}
+
+// absorbWrites records in snapshot the current state of the files rep reports as
+// written, so the writes do not trigger another batch. A file whose content is no
+// longer what was written has been edited since and keeps its old state, so the edit
+// is picked up by the next scan.
+func (p *Processor) absorbWrites(snapshot map[string]fileState, rep *report) {
+	if rep == nil {
+		return
+	}
+
+	for _, f := range rep.Files {
+		if f.AfterSHA256 == "" {
+			continue
+		}
+
+		info, err := p.fsys.Stat(f.Path)
+		if err != nil {
+			continue
+		}
+
+		data, err := p.fsys.ReadFile(f.Path)
+		if err != nil || sha256Hex(data) != f.AfterSHA256 {
+			continue
+		}
+
+		snapshot[f.Path] = fileState{modTime: info.ModTime(), size: info.Size()}
+	}
+}
+
+func (p *Processor) scan(ctx context.Context, roots []string) (map[string]fileState, error) {
+	files, err := p.collectFiles(ctx, roots)
+	if err != nil {
+		return nil, err
+	}
+
+	states := make(map[string]fileState, len(files))
+
+	for _, path := range files {
+		info, err := p.fsys.Stat(path)
+		if err != nil {
+			// The file disappeared between the walk and the stat.
+			continue
+		}
+
+		states[path] = fileState{modTime: info.ModTime(), size: info.Size()}
+	}
+
+	return states, nil
+}
+
+// isEditorArtifact reports whether name looks like a swap, backup, lock or temporary
+// file written by an editor. Editors that save atomically write such a file and rename
+// it over the original; only the renamed result is of interest. Emacs lock files such as
+// .#main.go end in .go but are dangling symbolic links, so the walk drops artifacts
+// before looking at the extension or following the entry.
+func isEditorArtifact(name string) bool {
+	switch {
+	case name == "4913": // Vim's probe file for directory write permissions.
+		return true
+	case strings.HasPrefix(name, ".#"), // Emacs lock files.
+		strings.HasPrefix(name, "#") && strings.HasSuffix(name, "#"), // Emacs auto-save files.
+		strings.HasSuffix(name, "~"),                                 // Backup files.
+		strings.HasPrefix(name, ".goutputstream-"),                   // GIO atomic saves.
+		strings.Contains(name, "___jb_"):                             // JetBrains safe writes.
+		return true
+	}
+
+	switch filepath.Ext(name) {
+	case ".swp", ".swo", ".swn", ".swx", ".tmp", ".bak", ".orig":
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestCollectFilesIgnoresEditorArtifacts(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"a.go":       "package m\n\nvar A = `a`\n",
+		"a.go~":      "package m\n",
+		".a.go.swp":  "swap",
+		"4913":       "",
+		"#a.go#":     "package m\n",
+		"sub/b.go":   "package m\n\nvar B = `b`\n",
+		"sub/b.go.1": "package m\n",
+	})
+
+	// Emacs lock files are dangling symbolic links.
+	if err := os.Symlink("user@host.1234:1700000000", filepath.Join(dir, ".#a.go")); err != nil {
+		t.Skip(err)
+	}
+
+	p, err := NewProcessor(defaultOptions(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := p.collectFiles(context.Background(), []string{dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{filepath.Join(dir, "a.go"), filepath.Join(dir, "sub", "b.go")}
+
+	if !slices.Equal(got, want) {
+		t.Errorf("collected %q, want %q", got, want)
+	}
+}
+
+func TestAbsorbWritesKeepsConcurrentEdits(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"written.go": "package m\n\nvar A = `a`\n",
+		"edited.go":  "package m\n\nvar B = `b`\n",
+		"other.go":   "package m\n\nvar C = `c`\n",
+	})
+
+	p, err := NewProcessor(defaultOptions(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	before, err := p.scan(context.Background(), []string{dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	written, edited, other := filepath.Join(dir, "written.go"), filepath.Join(dir, "edited.go"), filepath.Join(dir, "other.go")
+
+	// The batch writes written.go and edited.go; the user then saves edited.go again,
+	// and other.go, which the batch did not touch.
+	later := time.Now().Add(time.Hour)
+
+	for path, content := range map[string]string{
+		written: "package m\n\nvar A = \"a\"\n",
+		edited:  "package m\n\nvar B = \"b\" // saved\n",
+		other:   "package m\n\nvar C = `c` // saved\n",
+	} {
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.Chtimes(path, later, later); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rep := &report{Files: []fileReport{
+		{Path: written, AfterSHA256: sha256Hex([]byte("package m\n\nvar A = \"a\"\n"))},
+		{Path: edited, AfterSHA256: sha256Hex([]byte("package m\n\nvar B = \"b\"\n"))},
+	}}
+
+	snapshot := maps.Clone(before)
+	p.absorbWrites(snapshot, rep)
+
+	current, err := p.scan(context.Background(), []string{dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for path, wantModified := range map[string]bool{written: false, edited: true, other: true} {
+		if modified := snapshot[path] != current[path]; modified != wantModified {
+			t.Errorf("%s modified = %t, want %t", filepath.Base(path), modified, wantModified)
+		}
+	}
+}
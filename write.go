@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// writeFileDurable replaces filename with data such that, once it returns, either the
+// old or the new content is on stable storage, never a mix: data is written to a
+// temporary file in the same directory, synced, renamed over filename, and the
+// directory is synced so the rename itself survives a crash.
+func writeFileDurable(filename string, data []byte, perm os.FileMode) (err error) {
+	dir := filepath.Dir(filename)
+
+	// The .tmp suffix keeps watch mode from reacting to the temporary file.
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(filename)+".quotedconv-*.tmp")
+	if err != nil {
+		return fmt.Errorf("create temporary file: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	if _, err := tmp.Write(data); err != nil {
+		return fmt.Errorf("write temporary file: %w", err)
+	}
+
+	if err := tmp.Chmod(perm); err != nil {
+		return fmt.Errorf("chmod temporary file: %w", err)
+	}
+
+	if err := tmp.Sync(); err != nil {
+		return fmt.Errorf("sync temporary file: %w", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("close temporary file: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), filename); err != nil {
+		return fmt.Errorf("rename temporary file: %w", err)
+	}
+
+	if err := syncDir(dir); err != nil {
+		return fmt.Errorf("sync directory: %w", err)
+	}
+
+	return nil
+}